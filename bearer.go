@@ -0,0 +1,322 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"math/rand"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ConnectionOptionFunc is a function used to modify a Connection
+type ConnectionOptionFunc func(*Connection)
+
+// WithLatency specifies a constant delay added to each segment sent in either direction
+func WithLatency(latency time.Duration) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.bearer.latency = latency
+	}
+}
+
+// WithJitter specifies the maximum random delay added to each segment sent in either direction, on top of any
+// constant latency
+func WithJitter(jitter time.Duration) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.bearer.jitter = jitter
+	}
+}
+
+// WithBandwidth specifies the maximum throughput, in bytes per second, for each direction of the connection
+func WithBandwidth(bytesPerSecond uint64) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.bearer.bandwidth = bytesPerSecond
+	}
+}
+
+// WithRandomSeed specifies the seed for the generator used for jitter. The same seed always produces the same
+// sequence of delays
+func WithRandomSeed(seed int64) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.bearer.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// bearerQueueSize is the number of writes or reads that can be waiting for delivery in each direction
+const bearerQueueSize = 1024
+
+// bearerReadSize is the size of the buffer used to read from the underlying connection
+const bearerReadSize = 65536
+
+// bearerFlushTimeout is the maximum time to spend delivering queued writes when a connection is closed
+const bearerFlushTimeout = time.Second
+
+// bearerConfig simulates the characteristics of a network bearer by delaying the delivery of data
+type bearerConfig struct {
+	latency   time.Duration
+	jitter    time.Duration
+	bandwidth uint64
+	rng       *rand.Rand
+	rngMutex  sync.Mutex
+}
+
+// enabled reports whether any bearer simulation has been configured
+func (b *bearerConfig) enabled() bool {
+	return b.latency > 0 || b.jitter > 0 || b.bandwidth > 0
+}
+
+// propagationDelay returns the time for data to travel across the bearer once it has been transmitted
+func (b *bearerConfig) propagationDelay() time.Duration {
+	ret := b.latency
+	if b.jitter > 0 {
+		b.rngMutex.Lock()
+		if b.rng == nil {
+			b.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		ret += time.Duration(b.rng.Int63n(int64(b.jitter) + 1))
+		b.rngMutex.Unlock()
+	}
+	return ret
+}
+
+// transmitTime returns the time to transmit the provided number of bytes at the configured bandwidth
+func (b *bearerConfig) transmitTime(size int) time.Duration {
+	if b.bandwidth == 0 {
+		return 0
+	}
+	return time.Duration(uint64(size) * uint64(time.Second) / b.bandwidth)
+}
+
+// delayedData is data waiting to be delivered in one direction of a bearer
+type delayedData struct {
+	data []byte
+	err  error
+	due  time.Time
+}
+
+// delayLine queues the data sent in one direction of a bearer, stamped with the time it's due to be delivered.
+// Transmission times add up when data is sent faster than the bandwidth allows, while latency and jitter apply to
+// each delivery separately. Data is always delivered in the order it was sent
+type delayLine struct {
+	config  *bearerConfig
+	queue   chan delayedData
+	mutex   sync.Mutex
+	txEnd   time.Time
+	lastDue time.Time
+}
+
+func newDelayLine(config *bearerConfig) *delayLine {
+	return &delayLine{
+		config: config,
+		queue:  make(chan delayedData, bearerQueueSize),
+	}
+}
+
+// schedule returns the time when data of the provided size that is sent now is due to be delivered
+func (d *delayLine) schedule(size int) time.Time {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	txStart := time.Now()
+	if txStart.Before(d.txEnd) {
+		txStart = d.txEnd
+	}
+	d.txEnd = txStart.Add(d.config.transmitTime(size))
+	due := d.txEnd.Add(d.config.propagationDelay())
+	if due.Before(d.lastDue) {
+		due = d.lastDue
+	}
+	d.lastDue = due
+	return due
+}
+
+// bearerConn wraps the mocked side of a connection to delay the data in both directions according to the bearer
+// config. Writes are queued and passed to the underlying connection when they are due, and data read from the
+// underlying connection is held back until it's due
+type bearerConn struct {
+	net.Conn
+	out        *delayLine
+	in         *delayLine
+	writeMutex sync.Mutex
+	writeErr   error
+	readMutex  sync.Mutex
+	readBuf    []byte
+	readErr    error
+	closeChan  chan struct{}
+	onceClose  sync.Once
+	writeDone  chan struct{}
+	readDone   chan struct{}
+}
+
+func newBearerConn(conn net.Conn, config *bearerConfig) *bearerConn {
+	b := &bearerConn{
+		Conn:      conn,
+		out:       newDelayLine(config),
+		in:        newDelayLine(config),
+		closeChan: make(chan struct{}),
+		writeDone: make(chan struct{}),
+		readDone:  make(chan struct{}),
+	}
+	go b.writeLoop()
+	go b.readLoop()
+	return b
+}
+
+// Write queues the data for delivery to the underlying connection when it's due. Errors from delivering earlier
+// writes are returned by later calls
+func (b *bearerConn) Write(p []byte) (int, error) {
+	b.writeMutex.Lock()
+	defer b.writeMutex.Unlock()
+	if b.writeErr != nil {
+		return 0, b.writeErr
+	}
+	item := delayedData{
+		data: slices.Clone(p),
+		due:  b.out.schedule(len(p)),
+	}
+	select {
+	case <-b.closeChan:
+		return 0, net.ErrClosed
+	case b.out.queue <- item:
+	}
+	return len(p), nil
+}
+
+// Read returns data from the underlying connection once it's due
+func (b *bearerConn) Read(p []byte) (int, error) {
+	b.readMutex.Lock()
+	defer b.readMutex.Unlock()
+	if len(b.readBuf) == 0 {
+		if b.readErr != nil {
+			return 0, b.readErr
+		}
+		var item delayedData
+		select {
+		case <-b.closeChan:
+			return 0, net.ErrClosed
+		case item = <-b.in.queue:
+		}
+		timer := time.NewTimer(time.Until(item.due))
+		defer timer.Stop()
+		select {
+		case <-b.closeChan:
+			return 0, net.ErrClosed
+		case <-timer.C:
+		}
+		b.readBuf = item.data
+		b.readErr = item.err
+		if len(b.readBuf) == 0 {
+			return 0, b.readErr
+		}
+	}
+	n := copy(p, b.readBuf)
+	b.readBuf = b.readBuf[n:]
+	return n, nil
+}
+
+// Close delivers any queued writes without waiting for them to be due, and then closes the underlying connection
+func (b *bearerConn) Close() error {
+	var err error
+	b.onceClose.Do(func() {
+		close(b.closeChan)
+		// Don't wait indefinitely for a peer that isn't reading, including for a write that's already in progress
+		_ = b.Conn.SetWriteDeadline(time.Now().Add(bearerFlushTimeout))
+		<-b.writeDone
+		err = b.Conn.Close()
+		<-b.readDone
+	})
+	return err
+}
+
+// waitDelivered waits until all of the writes queued so far are due, or until the provided channel or the connection
+// is closed
+func (b *bearerConn) waitDelivered(stopChan <-chan any) {
+	b.out.mutex.Lock()
+	lastDue := b.out.lastDue
+	b.out.mutex.Unlock()
+	timer := time.NewTimer(time.Until(lastDue))
+	defer timer.Stop()
+	select {
+	case <-stopChan:
+	case <-b.closeChan:
+	case <-b.writeDone:
+	case <-timer.C:
+	}
+}
+
+// writeLoop passes queued writes to the underlying connection when they are due. Any writes still queued when the
+// connection is closed are delivered immediately before returning
+func (b *bearerConn) writeLoop() {
+	defer close(b.writeDone)
+	for {
+		select {
+		case item := <-b.out.queue:
+			if !b.deliver(item) {
+				return
+			}
+		case <-b.closeChan:
+			for {
+				select {
+				case item := <-b.out.queue:
+					if !b.deliver(item) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver waits until the provided data is due, or the connection is closed, and writes it to the underlying
+// connection. It returns false if the write fails
+func (b *bearerConn) deliver(item delayedData) bool {
+	timer := time.NewTimer(time.Until(item.due))
+	select {
+	case <-b.closeChan:
+	case <-timer.C:
+	}
+	timer.Stop()
+	if _, err := b.Conn.Write(item.data); err != nil {
+		b.writeMutex.Lock()
+		b.writeErr = err
+		b.writeMutex.Unlock()
+		return false
+	}
+	return true
+}
+
+// readLoop reads from the underlying connection and queues the data to be returned by Read when it's due
+func (b *bearerConn) readLoop() {
+	defer close(b.readDone)
+	buf := make([]byte, bearerReadSize)
+	for {
+		n, err := b.Conn.Read(buf)
+		item := delayedData{
+			data: slices.Clone(buf[:n]),
+			err:  err,
+			due:  b.in.schedule(n),
+		}
+		select {
+		case <-b.closeChan:
+			return
+		case b.in.queue <- item:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
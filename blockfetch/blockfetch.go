@@ -0,0 +1,195 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockfetch
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	gouroboros_blockfetch "github.com/blinklabs-io/gouroboros/protocol/blockfetch"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// interruptDelay is the time to wait after sending a partial batch before disconnecting
+const interruptDelay = 100 * time.Millisecond
+
+// ConversationEntryRequestRange is a pre-defined conversation entry that matches a RequestRange message from a
+// client
+var ConversationEntryRequestRange = ouroboros_mock.ConversationEntryInput{
+	ProtocolId:  gouroboros_blockfetch.ProtocolId,
+	MessageType: gouroboros_blockfetch.MessageTypeRequestRange,
+}
+
+// ConversationEntryNoBlocks is a pre-defined conversation entry for a NoBlocks response
+var ConversationEntryNoBlocks = ouroboros_mock.ConversationEntryOutput{
+	ProtocolId: gouroboros_blockfetch.ProtocolId,
+	IsResponse: true,
+	Messages: []protocol.Message{
+		gouroboros_blockfetch.NewMsgNoBlocks(),
+	},
+}
+
+// ConversationNoBlocks is a pre-defined conversation with a NtN handshake that responds to a range request with
+// NoBlocks
+var ConversationNoBlocks = []ouroboros_mock.ConversationEntry{
+	ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+	ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+	ConversationEntryRequestRange,
+	ConversationEntryNoBlocks,
+}
+
+// NewMsgBlock returns a Block message containing the provided block
+func NewMsgBlock(block blocks.Block) (*gouroboros_blockfetch.MsgBlock, error) {
+	wrappedBlock := gouroboros_blockfetch.WrappedBlock{
+		Type:     block.BlockType,
+		RawBlock: cbor.RawMessage(block.Cbor),
+	}
+	wrappedBlockCbor, err := cbor.Encode(wrappedBlock)
+	if err != nil {
+		return nil, err
+	}
+	return gouroboros_blockfetch.NewMsgBlock(wrappedBlockCbor), nil
+}
+
+// NewConversationEntryBatch returns a conversation entry that sends a complete batch containing the provided
+// blocks
+func NewConversationEntryBatch(
+	chain []blocks.Block,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	ret, err := newConversationEntryPartialBatch(chain)
+	if err != nil {
+		return ret, err
+	}
+	ret.Messages = append(ret.Messages, gouroboros_blockfetch.NewMsgBatchDone())
+	return ret, nil
+}
+
+// newConversationEntryPartialBatch returns a conversation entry that starts a batch and sends the provided
+// blocks without completing the batch
+func newConversationEntryPartialBatch(
+	chain []blocks.Block,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	ret := ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: gouroboros_blockfetch.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			gouroboros_blockfetch.NewMsgStartBatch(),
+		},
+	}
+	for _, block := range chain {
+		msg, err := NewMsgBlock(block)
+		if err != nil {
+			return ret, err
+		}
+		ret.Messages = append(ret.Messages, msg)
+	}
+	return ret, nil
+}
+
+// NewConversationRangeSpanningRollback returns a conversation with a NtN handshake where the first range request
+// is answered with NoBlocks, because the requested range spans a rollback, and the follow-up range request is
+// answered with the blocks from the new fork
+func NewConversationRangeSpanningRollback(
+	fork []blocks.Block,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	batch, err := NewConversationEntryBatch(fork)
+	if err != nil {
+		return nil, err
+	}
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+		ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+		ConversationEntryRequestRange,
+		ConversationEntryNoBlocks,
+		ConversationEntryRequestRange,
+		batch,
+	}, nil
+}
+
+// NewConversationInterruptedBatch returns a conversation with a NtN handshake that starts a batch in response to
+// a range request and disconnects after sending the first count blocks of the provided chain. It returns an error if
+// count is negative
+func NewConversationInterruptedBatch(
+	chain []blocks.Block,
+	count int,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid block count: %d", count)
+	}
+	if count > len(chain) {
+		count = len(chain)
+	}
+	batch, err := newConversationEntryPartialBatch(chain[:count])
+	if err != nil {
+		return nil, err
+	}
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+		ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+		ConversationEntryRequestRange,
+		batch,
+		// Give the client a chance to process the partial batch before disconnecting
+		ouroboros_mock.ConversationEntrySleep{Duration: interruptDelay},
+		ouroboros_mock.ConversationEntryClose{},
+	}, nil
+}
+
+// ServeRangeFromChain returns a conversation entry that matches a RequestRange message from a client and responds
+// with a batch containing the requested blocks from the provided chain, or NoBlocks if either end of the range
+// isn't in the chain
+func ServeRangeFromChain(chain []blocks.Block) ouroboros_mock.ConversationEntryResponder {
+	return ouroboros_mock.ConversationEntryResponder{
+		ProtocolId:      gouroboros_blockfetch.ProtocolId,
+		MsgFromCborFunc: gouroboros_blockfetch.NewMsgFromCbor,
+		ResponseFunc: func(msg protocol.Message) ([]ouroboros_mock.ConversationEntry, error) {
+			msgRequestRange, ok := msg.(*gouroboros_blockfetch.MsgRequestRange)
+			if !ok {
+				return nil, fmt.Errorf(
+					"input message is not of expected type: expected %d, got %d",
+					gouroboros_blockfetch.MessageTypeRequestRange,
+					msg.Type(),
+				)
+			}
+			startIdx := chainIndex(chain, msgRequestRange.Start)
+			endIdx := chainIndex(chain, msgRequestRange.End)
+			if startIdx < 0 || endIdx < startIdx {
+				return []ouroboros_mock.ConversationEntry{
+					ConversationEntryNoBlocks,
+				}, nil
+			}
+			batch, err := NewConversationEntryBatch(chain[startIdx : endIdx+1])
+			if err != nil {
+				return nil, err
+			}
+			return []ouroboros_mock.ConversationEntry{batch}, nil
+		},
+	}
+}
+
+// chainIndex returns the index of the block in the chain at the provided point, or -1 if there isn't one
+func chainIndex(chain []blocks.Block, point common.Point) int {
+	for idx, block := range chain {
+		if block.Slot == point.Slot && bytes.Equal(block.Hash, point.Hash) {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockfetch_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blockfetch"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	gouroboros_blockfetch "github.com/blinklabs-io/gouroboros/protocol/blockfetch"
+	"go.uber.org/goleak"
+)
+
+func buildTestChain(t *testing.T, seed int64) []blocks.Block {
+	chain, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithRandomSeed(seed),
+	).
+		AddBlocks(ledger.EraIdBabbage, 5).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	return chain
+}
+
+func newTestConnection(
+	t *testing.T,
+	conversation []ouroboros_mock.ConversationEntry,
+	blockChan chan ledger.Block,
+) *ouroboros.Connection {
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithBlockFetchConfig(
+			gouroboros_blockfetch.NewConfig(
+				gouroboros_blockfetch.WithBlockFunc(
+					func(_ gouroboros_blockfetch.CallbackContext, _ uint, block ledger.Block) error {
+						blockChan <- block
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	return oConn
+}
+
+func waitForShutdown(t *testing.T, oConn *ouroboros.Connection) {
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestNoBlocks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t, 0)
+	oConn := newTestConnection(t, blockfetch.ConversationNoBlocks, nil)
+	_, err := oConn.BlockFetch().Client.GetBlock(chain[0].Point())
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	waitForShutdown(t, oConn)
+}
+
+func TestRangeSpanningRollback(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t, 0)
+	fork := buildTestChain(t, 1)
+	conversation, err := blockfetch.NewConversationRangeSpanningRollback(fork)
+	if err != nil {
+		t.Fatalf("unexpected error creating conversation: %s", err)
+	}
+	blockChan := make(chan ledger.Block, len(fork))
+	oConn := newTestConnection(t, conversation, blockChan)
+	// The original range spans the rollback
+	err = oConn.BlockFetch().Client.GetBlockRange(
+		chain[0].Point(),
+		chain[len(chain)-1].Point(),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	// The range from the new fork is served
+	err = oConn.BlockFetch().Client.GetBlockRange(
+		fork[0].Point(),
+		fork[len(fork)-1].Point(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when requesting range: %s", err)
+	}
+	for idx, block := range fork {
+		select {
+		case blk := <-blockChan:
+			if blk.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("block %d did not have expected hash: got %s, expected %x", idx, blk.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive block %d within timeout", idx)
+		}
+	}
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	waitForShutdown(t, oConn)
+}
+
+func TestInterruptedBatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t, 0)
+	conversation, err := blockfetch.NewConversationInterruptedBatch(chain, 2)
+	if err != nil {
+		t.Fatalf("unexpected error creating conversation: %s", err)
+	}
+	blockChan := make(chan ledger.Block, len(chain))
+	oConn := newTestConnection(t, conversation, blockChan)
+	err = oConn.BlockFetch().Client.GetBlockRange(
+		chain[0].Point(),
+		chain[len(chain)-1].Point(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when requesting range: %s", err)
+	}
+	for idx := 0; idx < 2; idx++ {
+		select {
+		case <-blockChan:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive block %d within timeout", idx)
+		}
+	}
+	// The connection should be closed before the batch completes
+	select {
+	case err, ok := <-oConn.ErrorChan():
+		if ok && err == nil {
+			t.Fatalf("did not receive expected error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connection was not closed within timeout")
+	}
+	if len(blockChan) != 0 {
+		t.Fatalf("received unexpected additional blocks")
+	}
+	_ = oConn.Close()
+}
+
+func TestInterruptedBatchNegativeCount(t *testing.T) {
+	chain := buildTestChain(t, 0)
+	if _, err := blockfetch.NewConversationInterruptedBatch(chain, -1); err == nil {
+		t.Fatalf("did not get expected error creating conversation with negative count")
+	}
+}
+
+func TestServeRangeFromChain(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t, 0)
+	otherChain := buildTestChain(t, 1)
+	conversation := []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+		ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+		blockfetch.ServeRangeFromChain(chain),
+		blockfetch.ServeRangeFromChain(chain),
+	}
+	blockChan := make(chan ledger.Block, len(chain))
+	oConn := newTestConnection(t, conversation, blockChan)
+	// A range outside of the chain isn't served
+	err := oConn.BlockFetch().Client.GetBlockRange(
+		otherChain[0].Point(),
+		otherChain[1].Point(),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	// A range within the chain is served
+	err = oConn.BlockFetch().Client.GetBlockRange(
+		chain[1].Point(),
+		chain[3].Point(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when requesting range: %s", err)
+	}
+	for idx, block := range chain[1:4] {
+		select {
+		case blk := <-blockChan:
+			if blk.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("block %d did not have expected hash: got %s, expected %x", idx, blk.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive block %d within timeout", idx)
+		}
+	}
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	waitForShutdown(t, oConn)
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// Block is a generated block along with the metadata needed to serve it
+type Block struct {
+	EraId       uint
+	BlockType   uint
+	BlockNumber uint64
+	Slot        uint64
+	Hash        []byte
+	PrevHash    []byte
+	HeaderCbor  []byte
+	Cbor        []byte
+	// EpochNonce is the nonce for the block's epoch, or nil for the neutral nonce
+	EpochNonce []byte
+}
+
+// Point returns the chain point for the block
+func (b Block) Point() common.Point {
+	return common.NewPoint(b.Slot, b.Hash)
+}
+
+// Tip returns a chainsync tip which points at the block
+func (b Block) Tip() chainsync.Tip {
+	return chainsync.Tip{
+		Point:       b.Point(),
+		BlockNumber: b.BlockNumber,
+	}
+}
+
+// HeaderType returns the era tag used when wrapping the block header for NtN chainsync
+func (b Block) HeaderType() uint {
+	return headerTypeForBlockType(b.BlockType)
+}
+
+// ByronType returns the Byron block sub-type used when wrapping the block header for NtN chainsync.
+// This value is ignored by non-Byron blocks
+func (b Block) ByronType() uint {
+	return byronTypeForBlockType(b.BlockType)
+}
+
+// Header returns the header of the block, for serving with NtN chainsync without the block body
+func (b Block) Header() Header {
+	return Header{
+		BlockType: b.BlockType,
+		BlockSize: uint(len(b.Cbor)),
+		Cbor:      b.HeaderCbor,
+	}
+}
+
+// Header is a block header along with the metadata needed to wrap it for NtN chainsync
+type Header struct {
+	BlockType uint
+	// BlockSize is the size of the full block, which is only included in the wrapped header for Byron blocks
+	BlockSize uint
+	Cbor      []byte
+}
+
+// HeaderType returns the era tag used when wrapping the header for NtN chainsync
+func (h Header) HeaderType() uint {
+	return headerTypeForBlockType(h.BlockType)
+}
+
+// ByronType returns the Byron block sub-type used when wrapping the header for NtN chainsync. This value is
+// ignored by non-Byron headers
+func (h Header) ByronType() uint {
+	return byronTypeForBlockType(h.BlockType)
+}
+
+func headerTypeForBlockType(blockType uint) uint {
+	if blockType == ledger.BlockTypeByronEbb ||
+		blockType == ledger.BlockTypeByronMain {
+		return ledger.BlockHeaderTypeByron
+	}
+	return ledger.BlockToBlockHeaderTypeMap[blockType]
+}
+
+func byronTypeForBlockType(blockType uint) uint {
+	if blockType == ledger.BlockTypeByronEbb {
+		return ledger.BlockTypeByronEbb
+	}
+	return ledger.BlockTypeByronMain
+}
+
+// ChainTip returns a chainsync tip which points at the last block of the provided chain
+func ChainTip(chain []Block) chainsync.Tip {
+	if len(chain) == 0 {
+		return chainsync.Tip{
+			Point: common.NewPointOrigin(),
+		}
+	}
+	return chain[len(chain)-1].Tip()
+}
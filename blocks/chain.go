@@ -0,0 +1,465 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"fmt"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/byron"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// protocolVersion is a major/minor protocol version pair
+type protocolVersion struct {
+	Major uint64
+	Minor uint64
+}
+
+// eraInfo describes how to build blocks for a particular era
+type eraInfo struct {
+	BlockType       uint
+	ProtocolVersion protocolVersion
+}
+
+// eraInfoMap maps supported era IDs to the information needed to build blocks for them
+var eraInfoMap = map[uint]eraInfo{
+	ledger.EraIdByron: {
+		BlockType:       ledger.BlockTypeByronMain,
+		ProtocolVersion: protocolVersion{1, 0},
+	},
+	ledger.EraIdShelley: {
+		BlockType:       ledger.BlockTypeShelley,
+		ProtocolVersion: protocolVersion{2, 0},
+	},
+	ledger.EraIdAllegra: {
+		BlockType:       ledger.BlockTypeAllegra,
+		ProtocolVersion: protocolVersion{3, 0},
+	},
+	ledger.EraIdMary: {
+		BlockType:       ledger.BlockTypeMary,
+		ProtocolVersion: protocolVersion{4, 0},
+	},
+	ledger.EraIdAlonzo: {
+		BlockType:       ledger.BlockTypeAlonzo,
+		ProtocolVersion: protocolVersion{6, 0},
+	},
+	ledger.EraIdBabbage: {
+		BlockType:       ledger.BlockTypeBabbage,
+		ProtocolVersion: protocolVersion{8, 0},
+	},
+	ledger.EraIdConway: {
+		BlockType:       ledger.BlockTypeConway,
+		ProtocolVersion: protocolVersion{9, 0},
+	},
+}
+
+// MultiEraChainBuilder builds a linked chain of blocks which may span multiple eras
+type MultiEraChainBuilder struct {
+	networkMagic uint32
+	genesisHash  []byte
+	startSlot    uint64
+	slotInterval uint64
+	segments     []chainSegment
+	issuerVkey   []byte
+	vrfKey       []byte
+	vrfResult    *VrfResult
+	opCert       *OpCert
+	kesSignature []byte
+	randomSeed   int64
+	epochNonce   []byte
+	epochLength  uint64
+}
+
+// chainSegment is a run of blocks from a single era
+type chainSegment struct {
+	eraId uint
+	count int
+}
+
+// ChainBuilderOptionFunc is a function used to modify a MultiEraChainBuilder
+type ChainBuilderOptionFunc func(*MultiEraChainBuilder)
+
+// NewMultiEraChainBuilder returns a new MultiEraChainBuilder with the provided options applied
+func NewMultiEraChainBuilder(
+	opts ...ChainBuilderOptionFunc,
+) *MultiEraChainBuilder {
+	b := &MultiEraChainBuilder{
+		networkMagic: ouroboros_mock.MockNetworkMagic,
+		genesisHash: common.Blake2b256Hash(
+			[]byte("ouroboros-mock genesis"),
+		).Bytes(),
+		slotInterval: 1,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithNetworkMagic specifies the network magic used in Byron block headers
+func WithNetworkMagic(networkMagic uint32) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.networkMagic = networkMagic
+	}
+}
+
+// WithGenesisHash specifies the hash used as the previous block hash for the first block
+func WithGenesisHash(genesisHash []byte) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.genesisHash = genesisHash
+	}
+}
+
+// WithStartSlot specifies the slot of the first block
+func WithStartSlot(slot uint64) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.startSlot = slot
+	}
+}
+
+// WithSlotInterval specifies the number of slots between consecutive blocks
+func WithSlotInterval(interval uint64) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.slotInterval = interval
+	}
+}
+
+// AddBlocks appends the specified number of blocks from the specified era to the chain. Eras must be added
+// in chronological order
+func (b *MultiEraChainBuilder) AddBlocks(
+	eraId uint,
+	count int,
+) *MultiEraChainBuilder {
+	b.segments = append(
+		b.segments,
+		chainSegment{
+			eraId: eraId,
+			count: count,
+		},
+	)
+	return b
+}
+
+// Build generates the chain of blocks
+func (b *MultiEraChainBuilder) Build() ([]Block, error) {
+	stream, err := b.Stream()
+	if err != nil {
+		return nil, err
+	}
+	var ret []Block
+	for {
+		block, ok, err := stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return ret, nil
+		}
+		ret = append(ret, block)
+	}
+}
+
+// BlockStream generates the blocks of a chain one at a time, so that very long chains don't need to be held in
+// memory
+type BlockStream struct {
+	builder      *MultiEraChainBuilder
+	headerGen    *headerGenerator
+	nonces       *nonceTracker
+	prevHash     []byte
+	slot         uint64
+	blockNumber  uint64
+	segmentIdx   int
+	segmentCount int
+	// endless keeps generating blocks from the last era after the end of the chain
+	endless bool
+}
+
+// Stream returns a BlockStream which generates the same chain as Build
+func (b *MultiEraChainBuilder) Stream() (*BlockStream, error) {
+	if err := b.validateHeaderOptions(); err != nil {
+		return nil, err
+	}
+	for idx, segment := range b.segments {
+		if _, ok := eraInfoMap[segment.eraId]; !ok {
+			return nil, fmt.Errorf("unsupported era ID: %d", segment.eraId)
+		}
+		if idx > 0 && segment.eraId < b.segments[idx-1].eraId {
+			return nil, fmt.Errorf(
+				"era ID %d cannot follow era ID %d",
+				segment.eraId,
+				b.segments[idx-1].eraId,
+			)
+		}
+	}
+	return &BlockStream{
+		builder:   b,
+		headerGen: newHeaderGenerator(b),
+		nonces:    newNonceTracker(b),
+		prevHash:  b.genesisHash,
+		slot:      b.startSlot,
+	}, nil
+}
+
+// blockCount returns the number of blocks in the chain
+func (b *MultiEraChainBuilder) blockCount() int {
+	var ret int
+	for _, segment := range b.segments {
+		ret += segment.count
+	}
+	return ret
+}
+
+// Next returns the next block in the chain. It returns false when there are no more blocks
+func (s *BlockStream) Next() (Block, bool, error) {
+	b := s.builder
+	// Skip to the next segment with blocks remaining
+	for s.segmentIdx < len(b.segments) &&
+		s.segmentCount >= b.segments[s.segmentIdx].count {
+		if s.endless && s.segmentIdx == len(b.segments)-1 {
+			break
+		}
+		s.segmentIdx++
+		s.segmentCount = 0
+	}
+	if s.segmentIdx >= len(b.segments) {
+		return Block{}, false, nil
+	}
+	segment := b.segments[s.segmentIdx]
+	info := eraInfoMap[segment.eraId]
+	var block Block
+	var err error
+	fields := s.headerGen.next(s.slot)
+	epochNonce := s.nonces.next(segment.eraId, s.slot, s.prevHash, fields)
+	if segment.eraId == ledger.EraIdByron {
+		block, err = b.buildByronBlock(
+			s.blockNumber,
+			s.slot,
+			s.prevHash,
+			fields,
+		)
+	} else {
+		block, err = b.buildShelleyBlock(
+			segment.eraId,
+			info,
+			s.blockNumber,
+			s.slot,
+			s.prevHash,
+			fields,
+		)
+	}
+	if err != nil {
+		return Block{}, false, err
+	}
+	block.EpochNonce = epochNonce
+	s.prevHash = block.Hash
+	s.slot += b.slotInterval
+	s.blockNumber++
+	s.segmentCount++
+	return block, true, nil
+}
+
+func (b *MultiEraChainBuilder) buildByronBlock(
+	blockNumber uint64,
+	slot uint64,
+	prevHash []byte,
+	fields headerFields,
+) (Block, error) {
+	info := eraInfoMap[ledger.EraIdByron]
+	body := []any{
+		// Transaction payload
+		[]any{},
+		// SSC payload
+		[]any{uint64(3), map[uint]any{}},
+		// Delegation payload
+		[]any{},
+		// Update payload
+		[]any{[]any{}, []any{}},
+	}
+	bodyCbor, err := cbor.Encode(body)
+	if err != nil {
+		return Block{}, err
+	}
+	bodyHash := common.Blake2b256Hash(bodyCbor).Bytes()
+	header := []any{
+		b.networkMagic,
+		prevHash,
+		// Body proof
+		[]any{
+			[]any{uint64(0), bodyHash, bodyHash},
+			[]any{uint64(3), bodyHash, bodyHash},
+			bodyHash,
+			bodyHash,
+		},
+		// Consensus data
+		[]any{
+			[]any{
+				slot / byron.ByronSlotsPerEpoch,
+				slot % byron.ByronSlotsPerEpoch,
+			},
+			// Byron uses an extended public key, which we approximate with the issuer and VRF keys
+			append(append([]byte{}, fields.IssuerVkey...), fields.VrfKey...),
+			[]any{blockNumber},
+			[]any{uint64(0), fields.KesSignature[:byronSigSize]},
+		},
+		// Extra data
+		[]any{
+			[]any{
+				info.ProtocolVersion.Major,
+				info.ProtocolVersion.Minor,
+				uint64(0),
+			},
+			[]any{"cardano-sl", uint64(1)},
+			map[uint]any{},
+			bodyHash,
+		},
+	}
+	headerCbor, err := cbor.Encode(header)
+	if err != nil {
+		return Block{}, err
+	}
+	blockCbor, err := cbor.Encode(
+		[]any{
+			cbor.RawMessage(headerCbor),
+			cbor.RawMessage(bodyCbor),
+			[]any{map[uint]any{}},
+		},
+	)
+	if err != nil {
+		return Block{}, err
+	}
+	// The Byron block hash is calculated over the header wrapped in a list with the block type
+	blockHash := common.Blake2b256Hash(
+		append(
+			[]byte{0x82, byte(info.BlockType)},
+			headerCbor...,
+		),
+	)
+	return Block{
+		EraId:       ledger.EraIdByron,
+		BlockType:   info.BlockType,
+		BlockNumber: blockNumber,
+		Slot:        slot,
+		Hash:        blockHash.Bytes(),
+		PrevHash:    prevHash,
+		HeaderCbor:  headerCbor,
+		Cbor:        blockCbor,
+	}, nil
+}
+
+func (b *MultiEraChainBuilder) buildShelleyBlock(
+	eraId uint,
+	info eraInfo,
+	blockNumber uint64,
+	slot uint64,
+	prevHash []byte,
+	fields headerFields,
+) (Block, error) {
+	// Empty block body components
+	bodyParts := []any{
+		// Transaction bodies
+		[]any{},
+		// Transaction witness sets
+		[]any{},
+		// Auxiliary data
+		map[uint]any{},
+	}
+	if eraId >= ledger.EraIdAlonzo {
+		// Invalid transactions
+		bodyParts = append(bodyParts, []any{})
+	}
+	// The block body hash is the hash of the concatenated hashes of each body component
+	var bodyHashes []byte
+	var bodySize uint64
+	blockParts := make([]any, 1, len(bodyParts)+1)
+	for _, part := range bodyParts {
+		partCbor, err := cbor.Encode(part)
+		if err != nil {
+			return Block{}, err
+		}
+		bodyHashes = append(
+			bodyHashes,
+			common.Blake2b256Hash(partCbor).Bytes()...,
+		)
+		bodySize += uint64(len(partCbor))
+		blockParts = append(blockParts, cbor.RawMessage(partCbor))
+	}
+	bodyHash := common.Blake2b256Hash(bodyHashes).Bytes()
+	var headerBody []any
+	if eraId >= ledger.EraIdBabbage {
+		headerBody = []any{
+			blockNumber,
+			slot,
+			prevHash,
+			fields.IssuerVkey,
+			fields.VrfKey,
+			[]any{fields.LeaderVrf.Output, fields.LeaderVrf.Proof},
+			bodySize,
+			bodyHash,
+			[]any{
+				fields.OpCert.HotVkey,
+				fields.OpCert.SequenceNumber,
+				fields.OpCert.KesPeriod,
+				fields.OpCert.Signature,
+			},
+			[]any{info.ProtocolVersion.Major, info.ProtocolVersion.Minor},
+		}
+	} else {
+		headerBody = []any{
+			blockNumber,
+			slot,
+			prevHash,
+			fields.IssuerVkey,
+			fields.VrfKey,
+			[]any{fields.NonceVrf.Output, fields.NonceVrf.Proof},
+			[]any{fields.LeaderVrf.Output, fields.LeaderVrf.Proof},
+			bodySize,
+			bodyHash,
+			fields.OpCert.HotVkey,
+			fields.OpCert.SequenceNumber,
+			fields.OpCert.KesPeriod,
+			fields.OpCert.Signature,
+			info.ProtocolVersion.Major,
+			info.ProtocolVersion.Minor,
+		}
+	}
+	headerCbor, err := cbor.Encode(
+		[]any{
+			headerBody,
+			fields.KesSignature,
+		},
+	)
+	if err != nil {
+		return Block{}, err
+	}
+	blockParts[0] = cbor.RawMessage(headerCbor)
+	blockCbor, err := cbor.Encode(blockParts)
+	if err != nil {
+		return Block{}, err
+	}
+	return Block{
+		EraId:       eraId,
+		BlockType:   info.BlockType,
+		BlockNumber: blockNumber,
+		Slot:        slot,
+		Hash:        common.Blake2b256Hash(headerCbor).Bytes(),
+		PrevHash:    prevHash,
+		HeaderCbor:  headerCbor,
+		Cbor:        blockCbor,
+	}, nil
+}
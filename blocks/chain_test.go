@@ -0,0 +1,541 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"go.uber.org/goleak"
+)
+
+func buildTestChain(t *testing.T) []blocks.Block {
+	chain, err := blocks.NewMultiEraChainBuilder().
+		AddBlocks(ledger.EraIdByron, 5).
+		AddBlocks(ledger.EraIdShelley, 5).
+		AddBlocks(ledger.EraIdAlonzo, 5).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	return chain
+}
+
+func TestMultiEraChainBuilder(t *testing.T) {
+	chain := buildTestChain(t)
+	if len(chain) != 15 {
+		t.Fatalf("did not get expected number of blocks: got %d, expected %d", len(chain), 15)
+	}
+	expectedEras := []string{"Byron", "Shelley", "Alonzo"}
+	var prevHash string
+	for idx, block := range chain {
+		blk, err := ledger.NewBlockFromCbor(block.BlockType, block.Cbor)
+		if err != nil {
+			t.Fatalf("unexpected error decoding block %d: %s", idx, err)
+		}
+		if blk.Era().Name != expectedEras[idx/5] {
+			t.Fatalf("block %d did not have expected era: got %s, expected %s", idx, blk.Era().Name, expectedEras[idx/5])
+		}
+		if blk.Hash() != hex.EncodeToString(block.Hash) {
+			t.Fatalf("block %d did not have expected hash: got %s, expected %x", idx, blk.Hash(), block.Hash)
+		}
+		if blk.SlotNumber() != block.Slot {
+			t.Fatalf("block %d did not have expected slot: got %d, expected %d", idx, blk.SlotNumber(), block.Slot)
+		}
+		if idx > 0 && blk.PrevHash() != prevHash {
+			t.Fatalf("block %d did not link to previous block: got %s, expected %s", idx, blk.PrevHash(), prevHash)
+		}
+		prevHash = blk.Hash()
+	}
+}
+
+func TestMultiEraChainBuilderEraOrder(t *testing.T) {
+	_, err := blocks.NewMultiEraChainBuilder().
+		AddBlocks(ledger.EraIdShelley, 1).
+		AddBlocks(ledger.EraIdByron, 1).
+		Build()
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+}
+
+func TestChainSyncNtNConversationEntries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t)
+	conversation := append(
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+		},
+		blocks.ChainSyncNtNConversationEntries(chain)...,
+	)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	type rollForward struct {
+		blockType uint
+		header    ledger.BlockHeader
+	}
+	rollForwardChan := make(chan rollForward, len(chain))
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, blockType uint, blockData any, _ chainsync.Tip) error {
+						rollForwardChan <- rollForward{
+							blockType: blockType,
+							header:    blockData.(ledger.BlockHeader),
+						}
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	for idx, block := range chain {
+		select {
+		case rf := <-rollForwardChan:
+			if rf.header.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, rf.header.Hash(), block.Hash)
+			}
+			if rf.blockType != block.BlockType {
+				t.Fatalf("header %d did not have expected block type: got %d, expected %d", idx, rf.blockType, block.BlockType)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive header %d within timeout", idx)
+		}
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestChainSyncNtNConversationEntriesPipelinedClient(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t)
+	// The client sends its initial pipelined requests in a single segment
+	pipelineLimit := 3
+	conversation := append(
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+		},
+		blocks.ChainSyncNtNConversationEntries(chain)...,
+	)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	rollForwardChan := make(chan ledger.BlockHeader, len(chain))
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithPipelineLimit(pipelineLimit),
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						rollForwardChan <- blockData.(ledger.BlockHeader)
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	for idx, block := range chain {
+		select {
+		case header := <-rollForwardChan:
+			if header.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, header.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive header %d within timeout", idx)
+		}
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestChainSyncNtNPipelinedConversationEntries(t *testing.T) {
+	testDefs := []struct {
+		pipelineLimit int
+		depth         int
+	}{
+		{pipelineLimit: 0, depth: 1},
+		{pipelineLimit: 3, depth: 4},
+		{pipelineLimit: 3, depth: 5},
+		{pipelineLimit: 10, depth: 11},
+		{pipelineLimit: 10, depth: 20},
+	}
+	chain := buildTestChain(t)
+	for _, testDef := range testDefs {
+		t.Run(
+			fmt.Sprintf("limit %d depth %d", testDef.pipelineLimit, testDef.depth),
+			func(t *testing.T) {
+				defer goleak.VerifyNone(t)
+				err := runPipelinedClient(t, chain, testDef.pipelineLimit, testDef.depth)
+				if err != nil {
+					t.Fatalf("unexpected mock connection error: %s", err)
+				}
+			},
+		)
+	}
+}
+
+func TestChainSyncNtNPipelinedConversationEntriesTooManyRequests(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t)
+	// The client sends its initial 4 pipelined requests together, so the mock sees them queued
+	err := runPipelinedClient(t, chain, 3, 2)
+	if err == nil || !strings.Contains(err.Error(), "more than the maximum of 2") {
+		t.Fatalf("did not get expected mock connection error: got %v", err)
+	}
+}
+
+// runPipelinedClient syncs the provided chain with a NtN chainsync client using the specified pipeline limit,
+// from a mock connection serving the chain with the specified depth. It returns the error from the mock
+// connection, if any
+func runPipelinedClient(
+	t *testing.T,
+	chain []blocks.Block,
+	pipelineLimit int,
+	depth int,
+) error {
+	t.Helper()
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		append(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+			},
+			blocks.ChainSyncNtNPipelinedConversationEntries(chain, depth)...,
+		),
+	)
+	rollForwardChan := make(chan ledger.BlockHeader, len(chain))
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithPipelineLimit(pipelineLimit),
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						rollForwardChan <- blockData.(ledger.BlockHeader)
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	mockErrChan := mockConn.(*ouroboros_mock.Connection).ErrorChan()
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	var mockErr error
+	for idx := 0; idx < len(chain) && mockErr == nil; {
+		select {
+		case header := <-rollForwardChan:
+			if header.Hash() != hex.EncodeToString(chain[idx].Hash) {
+				t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, header.Hash(), chain[idx].Hash)
+			}
+			idx++
+		case err, ok := <-mockErrChan:
+			if !ok {
+				// The conversation finishing before the client has processed every header isn't an error
+				mockErrChan = nil
+				continue
+			}
+			mockErr = err
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive header %d within timeout", idx)
+		}
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil && mockErr == nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+	return mockErr
+}
+
+func TestChainSyncRollForwardNtNHeaderEntry(t *testing.T) {
+	chain := buildTestChain(t)
+	tip := blocks.ChainTip(chain)
+	for idx, block := range chain {
+		headerEntry, err := blocks.NewChainSyncRollForwardNtNHeaderEntry(block.Header(), tip)
+		if err != nil {
+			t.Fatalf("unexpected error building entry for block %d: %s", idx, err)
+		}
+		blockEntry := blocks.NewChainSyncRollForwardNtNEntry(block, tip)
+		headerCbor, err := cbor.Encode(headerEntry.Messages[0])
+		if err != nil {
+			t.Fatalf("unexpected error encoding header message for block %d: %s", idx, err)
+		}
+		blockCbor, err := cbor.Encode(blockEntry.Messages[0])
+		if err != nil {
+			t.Fatalf("unexpected error encoding block message for block %d: %s", idx, err)
+		}
+		// The header-only entry should be identical to the one built from the full block
+		if !bytes.Equal(headerCbor, blockCbor) {
+			t.Fatalf("header entry for block %d did not match block entry:\n  got:    %x\n  wanted: %x", idx, headerCbor, blockCbor)
+		}
+	}
+}
+
+func TestChainSyncNtNRollbackConversationEntries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t)
+	depth := 3
+	interval := 4
+	entries := blocks.ChainSyncNtNRollbackConversationEntries(chain, depth, interval)
+	if err := blocks.ValidateTips(entries); err != nil {
+		t.Fatalf("unexpected error validating tips: %s", err)
+	}
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		append(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+			},
+			entries...,
+		),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	// The callbacks maintain the chain as followed by the client, and report any errors
+	var followed [][]byte
+	var rollbacks int
+	doneChan := make(chan error, 1)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithRollBackwardFunc(
+					func(_ chainsync.CallbackContext, point common.Point, _ chainsync.Tip) error {
+						rollbacks++
+						for len(followed) > 0 && !bytes.Equal(followed[len(followed)-1], point.Hash) {
+							followed = followed[:len(followed)-1]
+						}
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						header := blockData.(ledger.BlockHeader)
+						if len(followed) >= len(chain) {
+							doneChan <- fmt.Errorf("received header past the end of the chain: %s", header.Hash())
+							return nil
+						}
+						expectedHash := hex.EncodeToString(chain[len(followed)].Hash)
+						if header.Hash() != expectedHash {
+							doneChan <- fmt.Errorf("header %d did not have expected hash: got %s, expected %s", len(followed), header.Hash(), expectedHash)
+							return nil
+						}
+						followed = append(followed, chain[len(followed)].Hash)
+						if len(followed) == len(chain) {
+							doneChan <- nil
+						}
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	select {
+	case err := <-doneChan:
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("did not follow chain within timeout")
+	}
+	// The initial rollback to the intersect, followed by a rollback after every interval blocks except the last
+	expectedRollbacks := 1 + (len(chain)-1)/interval
+	if rollbacks != expectedRollbacks {
+		t.Fatalf("did not get expected number of rollbacks: got %d, expected %d", rollbacks, expectedRollbacks)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestMultiEraChainBuilderHeaderFields(t *testing.T) {
+	issuerVkey := bytes.Repeat([]byte{0xab}, 32)
+	chain, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithIssuerVkey(issuerVkey),
+	).
+		AddBlocks(ledger.EraIdShelley, 1).
+		AddBlocks(ledger.EraIdConway, 1).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	for idx, block := range chain {
+		blk, err := ledger.NewBlockFromCbor(block.BlockType, block.Cbor)
+		if err != nil {
+			t.Fatalf("unexpected error decoding block %d: %s", idx, err)
+		}
+		vkey := blk.IssuerVkey()
+		if !bytes.Equal(vkey[:], issuerVkey) {
+			t.Fatalf("block %d did not have expected issuer vkey: got %x, expected %x", idx, vkey, issuerVkey)
+		}
+	}
+}
+
+func TestMultiEraChainBuilderPlausibleRandoms(t *testing.T) {
+	build := func(seed int64) []blocks.Block {
+		chain, err := blocks.NewMultiEraChainBuilder(
+			blocks.WithRandomSeed(seed),
+		).
+			AddBlocks(ledger.EraIdBabbage, 2).
+			Build()
+		if err != nil {
+			t.Fatalf("unexpected error building chain: %s", err)
+		}
+		return chain
+	}
+	chainA := build(1)
+	chainB := build(1)
+	chainC := build(2)
+	blk, err := ledger.NewBlockFromCbor(chainA[0].BlockType, chainA[0].Cbor)
+	if err != nil {
+		t.Fatalf("unexpected error decoding block: %s", err)
+	}
+	vkey := blk.IssuerVkey()
+	if bytes.Equal(vkey[:], make([]byte, 32)) {
+		t.Fatalf("issuer vkey was unexpectedly all zeroes")
+	}
+	if !bytes.Equal(chainA[1].Hash, chainB[1].Hash) {
+		t.Fatalf("chains built with the same seed did not match")
+	}
+	if bytes.Equal(chainA[1].Hash, chainC[1].Hash) {
+		t.Fatalf("chains built with different seeds unexpectedly matched")
+	}
+}
+
+func TestMultiEraChainBuilderInvalidHeaderField(t *testing.T) {
+	_, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithKesSignature([]byte{0x01}),
+	).
+		AddBlocks(ledger.EraIdShelley, 1).
+		Build()
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+}
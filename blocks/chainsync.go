@@ -0,0 +1,252 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// ConversationEntryChainSyncRequestNextNtN is a pre-defined conversation entry that matches a NtN chainsync
+// RequestNext message from a client
+var ConversationEntryChainSyncRequestNextNtN = ouroboros_mock.ConversationEntryInput{
+	ProtocolId:  chainsync.ProtocolIdNtN,
+	MessageType: chainsync.MessageTypeRequestNext,
+}
+
+// ConversationEntryChainSyncFindIntersectNtN is a pre-defined conversation entry that matches a NtN chainsync
+// FindIntersect message from a client
+var ConversationEntryChainSyncFindIntersectNtN = ouroboros_mock.ConversationEntryInput{
+	ProtocolId:  chainsync.ProtocolIdNtN,
+	MessageType: chainsync.MessageTypeFindIntersect,
+}
+
+// NewChainSyncRollForwardNtNEntry returns a conversation entry that sends a NtN chainsync RollForward for the
+// provided block, with the header wrapped using the era tag (and Byron sub-type) of the block
+func NewChainSyncRollForwardNtNEntry(
+	block Block,
+	tip chainsync.Tip,
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: chainsync.ProtocolIdNtN,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			chainsync.NewMsgRollForwardNtN(
+				block.HeaderType(),
+				block.ByronType(),
+				block.Cbor,
+				tip,
+			),
+		},
+	}
+}
+
+// NewChainSyncRollForwardNtNHeaderEntry returns a conversation entry that sends a NtN chainsync RollForward for
+// the provided header, wrapped using the era tag (and Byron sub-type) of the header. This doesn't require the
+// block body
+func NewChainSyncRollForwardNtNHeaderEntry(
+	header Header,
+	tip chainsync.Tip,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	headerTag := cbor.Tag{
+		Number:  cbor.CborTagCbor,
+		Content: header.Cbor,
+	}
+	var wrappedHeaderContent any = headerTag
+	if header.HeaderType() == ledger.BlockHeaderTypeByron {
+		// The Byron block size includes the 2 bytes of the era wrapper around the block
+		wrappedHeaderContent = []any{
+			[]any{header.ByronType(), header.BlockSize + 2},
+			headerTag,
+		}
+	}
+	wrappedHeaderCbor, err := cbor.Encode(
+		[]any{header.HeaderType(), wrappedHeaderContent},
+	)
+	if err != nil {
+		return ouroboros_mock.ConversationEntryOutput{}, err
+	}
+	msg := &chainsync.MsgRollForwardNtN{
+		MessageBase: protocol.MessageBase{
+			MessageType: chainsync.MessageTypeRollForward,
+		},
+		Tip: tip,
+	}
+	if _, err := cbor.Decode(wrappedHeaderCbor, &msg.WrappedHeader); err != nil {
+		return ouroboros_mock.ConversationEntryOutput{}, err
+	}
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: chainsync.ProtocolIdNtN,
+		IsResponse: true,
+		Messages:   []protocol.Message{msg},
+	}, nil
+}
+
+// ChainSyncNtNConversationEntries returns conversation entries that serve the provided chain to a NtN chainsync
+// client which syncs from the origin
+func ChainSyncNtNConversationEntries(
+	chain []Block,
+) []ouroboros_mock.ConversationEntry {
+	return ChainSyncNtNPipelinedConversationEntries(chain, 0)
+}
+
+// ChainSyncNtNPipelinedConversationEntries returns conversation entries that serve the provided chain to a NtN
+// chainsync client which syncs from the origin and pipelines its requests. Each RequestNext message is answered
+// as soon as it arrives, and the conversation fails if the client has more than depth requests waiting for a
+// response. A gouroboros client with a pipeline limit of N has up to N+1 requests in flight, so it needs a depth
+// of at least N+1. A depth of 0 doesn't limit the number of requests in flight
+func ChainSyncNtNPipelinedConversationEntries(
+	chain []Block,
+	depth int,
+) []ouroboros_mock.ConversationEntry {
+	return NewChainSyncPipelinedConversation(chain, depth).Render(false)
+}
+
+// ChainSyncNtNRollbackConversationEntries returns conversation entries that serve the provided chain to a NtN
+// chainsync client which syncs from the origin, with repeated rollbacks. See NewChainSyncRollbackConversation for
+// the meaning of depth and interval
+func ChainSyncNtNRollbackConversationEntries(
+	chain []Block,
+	depth int,
+	interval int,
+) []ouroboros_mock.ConversationEntry {
+	return NewChainSyncRollbackConversation(chain, depth, interval).Render(false)
+}
+
+// NewChainSyncConversation returns a chainsync conversation that serves the provided chain to a client which
+// syncs from the origin
+func NewChainSyncConversation(chain []Block) ChainSyncConversation {
+	return NewChainSyncPipelinedConversation(chain, 0)
+}
+
+// NewChainSyncPipelinedConversation returns a chainsync conversation that serves the provided chain to a client
+// which syncs from the origin and pipelines its requests. See ChainSyncNtNPipelinedConversationEntries for the
+// meaning of depth
+func NewChainSyncPipelinedConversation(
+	chain []Block,
+	depth int,
+) ChainSyncConversation {
+	tip := ChainTip(chain)
+	ret := ChainSyncConversation{
+		ChainSyncFindIntersect{},
+		ChainSyncIntersectFound{
+			Point: common.NewPointOrigin(),
+			Tip:   tip,
+		},
+	}
+	// The first response after finding the intersect is always a rollback to the intersect point
+	responses := []ChainSyncEntry{
+		ChainSyncRollBackward{
+			Point: common.NewPointOrigin(),
+			Tip:   tip,
+		},
+	}
+	for _, block := range chain {
+		responses = append(
+			responses,
+			ChainSyncRollForward{
+				Block: block,
+				Tip:   tip,
+			},
+		)
+	}
+	for _, response := range responses {
+		ret = append(
+			ret,
+			ChainSyncRequestNext{
+				MaxInFlight: depth,
+			},
+			response,
+		)
+	}
+	return ret
+}
+
+// NewChainSyncRollbackConversation returns a chainsync conversation that serves the provided chain to a client
+// which syncs from the origin, rolling back by the specified number of blocks after every interval blocks. The
+// rolled back blocks are then served again before the chain continues, since the chain builder doesn't generate
+// forks. Rollbacks past the first block go back to the origin, and there is no rollback after the last block. A
+// depth or interval of 0 disables the rollbacks
+func NewChainSyncRollbackConversation(
+	chain []Block,
+	depth int,
+	interval int,
+) ChainSyncConversation {
+	tip := ChainTip(chain)
+	ret := ChainSyncConversation{
+		ChainSyncFindIntersect{},
+		ChainSyncIntersectFound{
+			Point: common.NewPointOrigin(),
+			Tip:   tip,
+		},
+		// The first response after finding the intersect is always a rollback to the intersect point
+		ChainSyncRequestNext{},
+		ChainSyncRollBackward{
+			Point: common.NewPointOrigin(),
+			Tip:   tip,
+		},
+	}
+	for idx := 0; idx < len(chain); {
+		// Serve blocks up to the next rollback
+		end := len(chain)
+		if depth > 0 && interval > 0 {
+			end = min(idx+interval, len(chain))
+		}
+		ret = appendRollForwards(ret, chain[idx:end], tip)
+		idx = end
+		if idx == len(chain) {
+			break
+		}
+		rollbackIdx := max(idx-depth, 0)
+		rollbackPoint := common.NewPointOrigin()
+		if rollbackIdx > 0 {
+			rollbackPoint = chain[rollbackIdx-1].Point()
+		}
+		ret = append(
+			ret,
+			ChainSyncRequestNext{},
+			ChainSyncRollBackward{
+				Point: rollbackPoint,
+				Tip:   tip,
+			},
+		)
+		ret = appendRollForwards(ret, chain[rollbackIdx:idx], tip)
+	}
+	return ret
+}
+
+// appendRollForwards appends a request and RollForward to the conversation for each of the provided blocks
+func appendRollForwards(
+	conversation ChainSyncConversation,
+	chain []Block,
+	tip chainsync.Tip,
+) ChainSyncConversation {
+	for _, block := range chain {
+		conversation = append(
+			conversation,
+			ChainSyncRequestNext{},
+			ChainSyncRollForward{
+				Block: block,
+				Tip:   tip,
+			},
+		)
+	}
+	return conversation
+}
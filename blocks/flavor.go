@@ -0,0 +1,150 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// ChainSyncConversation is a chainsync conversation that isn't specific to NtN or NtC, so that a single
+// definition can be used to serve either kind of client
+type ChainSyncConversation []ChainSyncEntry
+
+// Render returns the conversation entries for a NtC client if isNtC is true, or a NtN client otherwise
+func (c ChainSyncConversation) Render(isNtC bool) []ouroboros_mock.ConversationEntry {
+	ret := make([]ouroboros_mock.ConversationEntry, 0, len(c))
+	for _, entry := range c {
+		ret = append(ret, entry.Render(isNtC))
+	}
+	return ret
+}
+
+// ChainSyncEntry is a chainsync conversation entry that isn't specific to NtN or NtC
+type ChainSyncEntry interface {
+	Render(isNtC bool) ouroboros_mock.ConversationEntry
+}
+
+// ChainSyncRequestNext matches a RequestNext message from a client. If MaxInFlight is set, the conversation fails
+// if the client has more than that many requests waiting for a response, including this one
+type ChainSyncRequestNext struct {
+	MaxInFlight int
+}
+
+func (e ChainSyncRequestNext) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId:  chainSyncProtocolId(isNtC),
+		MessageType: chainsync.MessageTypeRequestNext,
+		MaxQueued:   e.MaxInFlight,
+	}
+}
+
+// ChainSyncFindIntersect matches a FindIntersect message from a client
+type ChainSyncFindIntersect struct{}
+
+func (ChainSyncFindIntersect) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId:  chainSyncProtocolId(isNtC),
+		MessageType: chainsync.MessageTypeFindIntersect,
+	}
+}
+
+// ChainSyncIntersectFound sends an IntersectFound message with the provided point and tip
+type ChainSyncIntersectFound struct {
+	Point common.Point
+	Tip   chainsync.Tip
+}
+
+func (e ChainSyncIntersectFound) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return chainSyncOutput(isNtC, chainsync.NewMsgIntersectFound(e.Point, e.Tip))
+}
+
+// ChainSyncRollBackward sends a RollBackward message with the provided point and tip
+type ChainSyncRollBackward struct {
+	Point common.Point
+	Tip   chainsync.Tip
+}
+
+func (e ChainSyncRollBackward) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return chainSyncOutput(isNtC, chainsync.NewMsgRollBackward(e.Point, e.Tip))
+}
+
+// ChainSyncRollForward sends a RollForward message for the provided block and tip. NtN clients are sent the
+// wrapped block header and NtC clients are sent the full block
+type ChainSyncRollForward struct {
+	Block Block
+	Tip   chainsync.Tip
+}
+
+func (e ChainSyncRollForward) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	if !isNtC {
+		return NewChainSyncRollForwardNtNEntry(e.Block, e.Tip)
+	}
+	return chainSyncOutput(
+		isNtC,
+		chainsync.NewMsgRollForwardNtC(e.Block.BlockType, e.Block.Cbor, e.Tip),
+	)
+}
+
+// ChainSyncAwaitReply sends an AwaitReply message
+type ChainSyncAwaitReply struct{}
+
+func (ChainSyncAwaitReply) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return chainSyncOutput(isNtC, chainsync.NewMsgAwaitReply())
+}
+
+// ChainSyncSleep waits for the specified duration before continuing
+type ChainSyncSleep struct {
+	Duration time.Duration
+}
+
+func (e ChainSyncSleep) Render(bool) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntrySleep{
+		Duration: e.Duration,
+	}
+}
+
+// ChainSyncStall accepts the next message from a client and then sends nothing for the specified duration, or
+// until the connection is closed if the duration is zero
+type ChainSyncStall struct {
+	Duration time.Duration
+}
+
+func (e ChainSyncStall) Render(isNtC bool) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryStall{
+		ProtocolId: chainSyncProtocolId(isNtC),
+		Duration:   e.Duration,
+	}
+}
+
+func chainSyncProtocolId(isNtC bool) uint16 {
+	if isNtC {
+		return chainsync.ProtocolIdNtC
+	}
+	return chainsync.ProtocolIdNtN
+}
+
+func chainSyncOutput(isNtC bool, msg protocol.Message) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: chainSyncProtocolId(isNtC),
+		IsResponse: true,
+		Messages:   []protocol.Message{msg},
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"go.uber.org/goleak"
+)
+
+func TestChainSyncConversationRenderNtC(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain := buildTestChain(t)
+	conversation := append(
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+		},
+		blocks.NewChainSyncConversation(chain).Render(true)...,
+	)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	rollForwardChan := make(chan ledger.Block, len(chain))
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						rollForwardChan <- blockData.(ledger.Block)
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	for idx, block := range chain {
+		select {
+		case blk := <-rollForwardChan:
+			if blk.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("block %d did not have expected hash: got %s, expected %x", idx, blk.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive block %d within timeout", idx)
+		}
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestChainSyncConversationRenderNtN(t *testing.T) {
+	chain := buildTestChain(t)
+	entries := blocks.NewChainSyncConversation(chain).Render(false)
+	for idx, entry := range entries {
+		var protocolId uint16
+		switch entry := entry.(type) {
+		case ouroboros_mock.ConversationEntryInput:
+			protocolId = entry.ProtocolId
+		case ouroboros_mock.ConversationEntryOutput:
+			protocolId = entry.ProtocolId
+		default:
+			t.Fatalf("entry %d did not have expected type: got %T", idx, entry)
+		}
+		if protocolId != chainsync.ProtocolIdNtN {
+			t.Fatalf("entry %d did not have expected protocol ID: got %d, expected %d", idx, protocolId, chainsync.ProtocolIdNtN)
+		}
+	}
+}
+
+func TestChainSyncStall(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		append(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+			blocks.ChainSyncConversation{
+				blocks.ChainSyncStall{},
+			}.Render(true)...,
+		),
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithIntersectTimeout(100*time.Millisecond),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// The sync fails with the timeout error, which is checked below
+	go func() {
+		_ = oConn.ChainSync().Client.Sync(nil)
+	}()
+	select {
+	case err := <-oConn.ErrorChan():
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Fatalf("did not receive expected error: got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("did not receive timeout error within timeout")
+	}
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Sizes of the various key, hash, and signature fields in block headers
+const (
+	vkeySize         = 32
+	vrfOutputSize    = 64
+	vrfProofSize     = 80
+	opCertSigSize    = 64
+	kesSignatureSize = 448
+	byronSigSize     = 64
+)
+
+// slotsPerKesPeriod is the number of slots in a KES period on the public networks
+const slotsPerKesPeriod = 129600
+
+// VrfResult is a VRF output along with its proof
+type VrfResult struct {
+	Output []byte
+	Proof  []byte
+}
+
+// OpCert is the operational certificate included in Shelley-era and later block headers
+type OpCert struct {
+	HotVkey        []byte
+	SequenceNumber uint32
+	KesPeriod      uint32
+	Signature      []byte
+}
+
+// headerFields holds the issuer-related values used when building a single block header
+type headerFields struct {
+	IssuerVkey   []byte
+	VrfKey       []byte
+	NonceVrf     VrfResult
+	LeaderVrf    VrfResult
+	OpCert       OpCert
+	KesSignature []byte
+}
+
+// WithIssuerVkey specifies the block issuer verification key used in all block headers
+func WithIssuerVkey(vkey []byte) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.issuerVkey = vkey
+	}
+}
+
+// WithVrfKey specifies the VRF verification key used in all block headers
+func WithVrfKey(vkey []byte) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.vrfKey = vkey
+	}
+}
+
+// WithVrfResult specifies the VRF result used in all block headers. Pre-Babbage headers use this value for both
+// the nonce and leader VRF results
+func WithVrfResult(result VrfResult) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.vrfResult = &result
+	}
+}
+
+// WithOpCert specifies the operational certificate used in all block headers
+func WithOpCert(opCert OpCert) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.opCert = &opCert
+	}
+}
+
+// WithKesSignature specifies the KES signature used in all block headers
+func WithKesSignature(signature []byte) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.kesSignature = signature
+	}
+}
+
+// WithRandomSeed specifies the seed for the generator used to fill in plausible random values for any header
+// fields that aren't explicitly provided. The same seed always produces the same chain
+func WithRandomSeed(seed int64) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.randomSeed = seed
+	}
+}
+
+// fieldSizeCheck describes the expected size of an explicitly provided header field
+type fieldSizeCheck struct {
+	name string
+	data []byte
+	size int
+}
+
+// validateHeaderOptions checks that any explicitly provided header fields have the correct sizes
+func (b *MultiEraChainBuilder) validateHeaderOptions() error {
+	checks := []fieldSizeCheck{
+		{"issuer vkey", b.issuerVkey, vkeySize},
+		{"VRF key", b.vrfKey, vkeySize},
+		{"KES signature", b.kesSignature, kesSignatureSize},
+	}
+	if b.vrfResult != nil {
+		checks = append(
+			checks,
+			fieldSizeCheck{"VRF output", b.vrfResult.Output, vrfOutputSize},
+			fieldSizeCheck{"VRF proof", b.vrfResult.Proof, vrfProofSize},
+		)
+	}
+	if b.opCert != nil {
+		checks = append(
+			checks,
+			fieldSizeCheck{"opcert hot vkey", b.opCert.HotVkey, vkeySize},
+			fieldSizeCheck{"opcert signature", b.opCert.Signature, opCertSigSize},
+		)
+	}
+	for _, check := range checks {
+		if check.data != nil && len(check.data) != check.size {
+			return fmt.Errorf(
+				"invalid %s size: expected %d, got %d",
+				check.name,
+				check.size,
+				len(check.data),
+			)
+		}
+	}
+	return nil
+}
+
+// headerGenerator produces header fields for successive blocks, filling in plausible random values for any
+// fields that weren't explicitly provided
+type headerGenerator struct {
+	builder    *MultiEraChainBuilder
+	rng        *rand.Rand
+	issuerVkey []byte
+	vrfKey     []byte
+	hotVkey    []byte
+	opCertSig  []byte
+}
+
+func newHeaderGenerator(b *MultiEraChainBuilder) *headerGenerator {
+	g := &headerGenerator{
+		builder: b,
+		// #nosec G404
+		rng: rand.New(rand.NewSource(b.randomSeed)),
+	}
+	// The pool identity remains the same for the entire chain
+	g.issuerVkey = g.bytesOrRandom(b.issuerVkey, vkeySize)
+	g.vrfKey = g.bytesOrRandom(b.vrfKey, vkeySize)
+	g.hotVkey = g.randomBytes(vkeySize)
+	g.opCertSig = g.randomBytes(opCertSigSize)
+	return g
+}
+
+// next returns the header fields for a block in the specified slot
+func (g *headerGenerator) next(slot uint64) headerFields {
+	ret := headerFields{
+		IssuerVkey: g.issuerVkey,
+		VrfKey:     g.vrfKey,
+		NonceVrf: VrfResult{
+			Output: g.randomBytes(vrfOutputSize),
+			Proof:  g.randomBytes(vrfProofSize),
+		},
+		LeaderVrf: VrfResult{
+			Output: g.randomBytes(vrfOutputSize),
+			Proof:  g.randomBytes(vrfProofSize),
+		},
+		OpCert: OpCert{
+			HotVkey:   g.hotVkey,
+			KesPeriod: uint32(slot / slotsPerKesPeriod),
+			Signature: g.opCertSig,
+		},
+		KesSignature: g.bytesOrRandom(
+			g.builder.kesSignature,
+			kesSignatureSize,
+		),
+	}
+	if g.builder.vrfResult != nil {
+		ret.NonceVrf = *g.builder.vrfResult
+		ret.LeaderVrf = *g.builder.vrfResult
+	}
+	if g.builder.opCert != nil {
+		ret.OpCert = *g.builder.opCert
+	}
+	return ret
+}
+
+func (g *headerGenerator) bytesOrRandom(data []byte, size int) []byte {
+	if data != nil {
+		return data
+	}
+	return g.randomBytes(size)
+}
+
+func (g *headerGenerator) randomBytes(size int) []byte {
+	ret := make([]byte, size)
+	// This never returns an error
+	_, _ = g.rng.Read(ret)
+	return ret
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// defaultEpochLength is the number of slots in an epoch on the public networks
+const defaultEpochLength = 432000
+
+// WithEpochNonce specifies the initial epoch nonce, such as the Shelley genesis hash. The chain starts with the
+// neutral nonce by default
+func WithEpochNonce(nonce []byte) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.epochNonce = nonce
+	}
+}
+
+// WithEpochLength specifies the number of slots in an epoch, which controls when the epoch nonce changes
+func WithEpochLength(slots uint64) ChainBuilderOptionFunc {
+	return func(b *MultiEraChainBuilder) {
+		b.epochLength = slots
+	}
+}
+
+// nonceTracker evolves the epoch nonce as blocks are added to the chain
+type nonceTracker struct {
+	epochLength uint64
+	epoch       uint64
+	epochNonce  []byte
+	// evolvingNonce accumulates the VRF nonce of every block
+	evolvingNonce []byte
+	// candidateNonce follows the evolving nonce until the randomness stabilisation window near the end of the epoch
+	candidateNonce []byte
+	// lastEpochBlockNonce is the previous hash of the last block of the previous epoch
+	lastEpochBlockNonce []byte
+	// labNonce is the previous hash of the last block applied
+	labNonce []byte
+}
+
+func newNonceTracker(b *MultiEraChainBuilder) *nonceTracker {
+	epochLength := b.epochLength
+	if epochLength == 0 {
+		epochLength = defaultEpochLength
+	}
+	return &nonceTracker{
+		epochLength:    epochLength,
+		epoch:          b.startSlot / epochLength,
+		epochNonce:     b.epochNonce,
+		evolvingNonce:  b.epochNonce,
+		candidateNonce: b.epochNonce,
+	}
+}
+
+// next updates the nonces for a block and returns the epoch nonce for the block's epoch
+func (n *nonceTracker) next(
+	eraId uint,
+	slot uint64,
+	prevHash []byte,
+	fields headerFields,
+) []byte {
+	epoch := slot / n.epochLength
+	if epoch > n.epoch {
+		n.epochNonce = combineNonces(n.candidateNonce, n.lastEpochBlockNonce)
+		n.lastEpochBlockNonce = n.labNonce
+		n.epoch = epoch
+	}
+	// Byron blocks don't contribute to the nonce
+	if eraId != ledger.EraIdByron {
+		n.evolvingNonce = combineNonces(
+			n.evolvingNonce,
+			blockNonce(eraId, fields),
+		)
+		if slot+n.stabilisationWindow(eraId) < (epoch+1)*n.epochLength {
+			n.candidateNonce = n.evolvingNonce
+		}
+	}
+	n.labNonce = prevHash
+	return n.epochNonce
+}
+
+// stabilisationWindow returns the number of slots at the end of an epoch during which the candidate nonce is
+// frozen. This is 3k/f slots before Babbage and 4k/f slots after, which is 30% or 40% of the epoch on the public
+// networks
+func (n *nonceTracker) stabilisationWindow(eraId uint) uint64 {
+	if eraId >= ledger.EraIdBabbage {
+		return n.epochLength * 4 / 10
+	}
+	return n.epochLength * 3 / 10
+}
+
+// blockNonce returns the nonce contribution of a block, which is derived from its VRF output
+func blockNonce(eraId uint, fields headerFields) []byte {
+	if eraId >= ledger.EraIdBabbage {
+		// Praos derives the nonce from the single VRF result in the header
+		tmpHash := common.Blake2b256Hash(
+			append([]byte("N"), fields.LeaderVrf.Output...),
+		)
+		return common.Blake2b256Hash(tmpHash.Bytes()).Bytes()
+	}
+	return common.Blake2b256Hash(fields.NonceVrf.Output).Bytes()
+}
+
+// combineNonces returns the combination of two nonces, where a nil nonce is the neutral nonce
+func combineNonces(a []byte, b []byte) []byte {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return common.Blake2b256Hash(append(append([]byte{}, a...), b...)).Bytes()
+}
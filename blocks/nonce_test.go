@@ -0,0 +1,88 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+	lcommon "github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func combineTestNonces(a []byte, b []byte) []byte {
+	return lcommon.Blake2b256Hash(append(append([]byte{}, a...), b...)).Bytes()
+}
+
+func TestMultiEraChainBuilderEpochNonce(t *testing.T) {
+	initialNonce := lcommon.Blake2b256Hash([]byte("initial nonce")).Bytes()
+	vrfResult := blocks.VrfResult{
+		Output: bytes.Repeat([]byte{0x01}, 64),
+		Proof:  bytes.Repeat([]byte{0x02}, 80),
+	}
+	chain, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithEpochNonce(initialNonce),
+		blocks.WithEpochLength(10),
+		blocks.WithVrfResult(vrfResult),
+	).
+		AddBlocks(ledger.EraIdShelley, 25).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	// Every block contributes the same nonce, since they all use the same VRF result
+	vrfNonce := lcommon.Blake2b256Hash(vrfResult.Output).Bytes()
+	evolvingNonce := initialNonce
+	var candidateNonces [][]byte
+	for _, block := range chain {
+		evolvingNonce = combineTestNonces(evolvingNonce, vrfNonce)
+		// The candidate nonce is frozen for the last 3 slots of each epoch
+		if block.Slot%10 == 6 {
+			candidateNonces = append(candidateNonces, evolvingNonce)
+		}
+	}
+	expectedNonces := [][]byte{
+		initialNonce,
+		// There's no previous epoch block nonce at the first epoch boundary
+		candidateNonces[0],
+		combineTestNonces(candidateNonces[1], chain[8].Hash),
+	}
+	for _, block := range chain {
+		expectedNonce := expectedNonces[block.Slot/10]
+		if !bytes.Equal(block.EpochNonce, expectedNonce) {
+			t.Fatalf("did not get expected epoch nonce for slot %d: got %x, expected %x", block.Slot, block.EpochNonce, expectedNonce)
+		}
+	}
+}
+
+func TestMultiEraChainBuilderNeutralEpochNonce(t *testing.T) {
+	chain, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithEpochLength(10),
+	).
+		AddBlocks(ledger.EraIdByron, 10).
+		AddBlocks(ledger.EraIdShelley, 10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	// Byron blocks don't evolve the nonce, so the first Shelley epoch still has the neutral nonce
+	for _, block := range chain {
+		if block.EpochNonce != nil {
+			t.Fatalf("did not get expected neutral epoch nonce for slot %d: got %x", block.Slot, block.EpochNonce)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// ChainSyncStreamConversationEntries returns conversation entries that serve the chain from the provided builder
+// to a chainsync client which syncs from the origin. Blocks are generated as they are requested rather than up
+// front, so chains of any length can be served with constant memory usage. Since the final tip isn't known in
+// advance, each block is sent with itself as the tip. The entries can only be used for a single connection
+func ChainSyncStreamConversationEntries(
+	builder *MultiEraChainBuilder,
+	isNtC bool,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	stream, err := builder.Stream()
+	if err != nil {
+		return nil, err
+	}
+	originTip := chainsync.Tip{
+		Point: common.NewPointOrigin(),
+	}
+	ret := ChainSyncConversation{
+		ChainSyncFindIntersect{},
+		ChainSyncIntersectFound{
+			Point: common.NewPointOrigin(),
+			Tip:   originTip,
+		},
+		ChainSyncRequestNext{},
+		ChainSyncRollBackward{
+			Point: common.NewPointOrigin(),
+			Tip:   originTip,
+		},
+	}.Render(isNtC)
+	ret = append(
+		ret,
+		ouroboros_mock.ConversationEntryStream{
+			NextFunc: func() ([]ouroboros_mock.ConversationEntry, error) {
+				block, ok, err := stream.Next()
+				if err != nil || !ok {
+					return nil, err
+				}
+				return []ouroboros_mock.ConversationEntry{
+					ChainSyncRequestNext{}.Render(isNtC),
+					ChainSyncRollForward{
+						Block: block,
+						Tip:   block.Tip(),
+					}.Render(isNtC),
+				}, nil
+			},
+		},
+	)
+	return ret, nil
+}
+
+// ChainSyncLiveConversationEntries returns conversation entries that serve the chain from the provided builder to a
+// chainsync client which syncs from the origin, and then keep producing new blocks like a node at the tip of the
+// chain. The blocks from the builder are generated as they are requested, and once they run out, each request is
+// answered with AwaitReply followed by a new block from the last era after the specified interval. Blocks are
+// produced until the client disconnects or the connection is closed. As with ChainSyncStreamConversationEntries,
+// each block is sent with itself as the tip and the entries can only be used for a single connection
+func ChainSyncLiveConversationEntries(
+	builder *MultiEraChainBuilder,
+	interval time.Duration,
+	isNtC bool,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	if len(builder.segments) == 0 {
+		return nil, errors.New("chain builder has no blocks to continue from")
+	}
+	stream, err := builder.Stream()
+	if err != nil {
+		return nil, err
+	}
+	stream.endless = true
+	builtCount := builder.blockCount()
+	var servedCount int
+	originTip := chainsync.Tip{
+		Point: common.NewPointOrigin(),
+	}
+	msgFromCborFunc := chainsync.NewMsgFromCborNtN
+	if isNtC {
+		msgFromCborFunc = chainsync.NewMsgFromCborNtC
+	}
+	ret := ChainSyncConversation{
+		ChainSyncFindIntersect{},
+		ChainSyncIntersectFound{
+			Point: common.NewPointOrigin(),
+			Tip:   originTip,
+		},
+		ChainSyncRequestNext{},
+		ChainSyncRollBackward{
+			Point: common.NewPointOrigin(),
+			Tip:   originTip,
+		},
+	}.Render(isNtC)
+	ret = append(
+		ret,
+		ouroboros_mock.ConversationEntryLoop{
+			Entries: []ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryResponder{
+					ProtocolId:      chainSyncProtocolId(isNtC),
+					MsgFromCborFunc: msgFromCborFunc,
+					ResponseFunc: func(msg protocol.Message) ([]ouroboros_mock.ConversationEntry, error) {
+						switch msg.(type) {
+						case *chainsync.MsgRequestNext:
+							block, _, err := stream.Next()
+							if err != nil {
+								return nil, err
+							}
+							servedCount++
+							rollForward := ChainSyncRollForward{
+								Block: block,
+								Tip:   block.Tip(),
+							}
+							if servedCount <= builtCount {
+								return ChainSyncConversation{rollForward}.Render(isNtC), nil
+							}
+							return ChainSyncConversation{
+								ChainSyncAwaitReply{},
+								ChainSyncSleep{
+									Duration: interval,
+								},
+								rollForward,
+							}.Render(isNtC), nil
+						case *chainsync.MsgDone:
+							return nil, nil
+						default:
+							return nil, fmt.Errorf("unexpected chainsync message: %T", msg)
+						}
+					},
+				},
+			},
+		},
+	)
+	return ret, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"go.uber.org/goleak"
+)
+
+// syncStream serves the chain from the provided builder with a streaming conversation and syncs it with a NtN
+// chainsync client, calling headerFunc for each header received
+func syncStream(
+	tb testing.TB,
+	builder *blocks.MultiEraChainBuilder,
+	count int,
+	headerFunc func(ledger.BlockHeader),
+) {
+	entries, err := blocks.ChainSyncStreamConversationEntries(builder, false)
+	if err != nil {
+		tb.Fatalf("unexpected error creating stream entries: %s", err)
+	}
+	syncEntries(tb, entries, count, headerFunc)
+}
+
+// syncEntries serves the provided chainsync conversation entries and syncs count headers with a NtN chainsync
+// client, calling headerFunc for each header received
+func syncEntries(
+	tb testing.TB,
+	entries []ouroboros_mock.ConversationEntry,
+	count int,
+	headerFunc func(ledger.BlockHeader),
+) {
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		append(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+			},
+			entries...,
+		),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	doneChan := make(chan struct{})
+	received := 0
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						if headerFunc != nil {
+							headerFunc(blockData.(ledger.BlockHeader))
+						}
+						received++
+						if received == count {
+							close(doneChan)
+						}
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		tb.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		tb.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	select {
+	case <-doneChan:
+	case <-time.After(60 * time.Second):
+		tb.Fatalf("did not receive %d headers within timeout, got %d", count, received)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		tb.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		tb.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestChainSyncStreamConversationEntries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	newBuilder := func() *blocks.MultiEraChainBuilder {
+		return blocks.NewMultiEraChainBuilder(blocks.WithRandomSeed(1)).
+			AddBlocks(ledger.EraIdByron, 5).
+			AddBlocks(ledger.EraIdShelley, 5).
+			AddBlocks(ledger.EraIdBabbage, 5)
+	}
+	// The streamed chain is the same as the built chain
+	chain, err := newBuilder().Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	var hashes []string
+	syncStream(
+		t,
+		newBuilder(),
+		len(chain),
+		func(header ledger.BlockHeader) {
+			hashes = append(hashes, header.Hash())
+		},
+	)
+	for idx, block := range chain {
+		if hashes[idx] != hex.EncodeToString(block.Hash) {
+			t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, hashes[idx], block.Hash)
+		}
+	}
+}
+
+func TestChainSyncLiveConversationEntries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	newBuilder := func() *blocks.MultiEraChainBuilder {
+		return blocks.NewMultiEraChainBuilder().
+			AddBlocks(ledger.EraIdByron, 5).
+			AddBlocks(ledger.EraIdShelley, 5)
+	}
+	chain, err := newBuilder().Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	interval := 50 * time.Millisecond
+	liveCount := 5
+	entries, err := blocks.ChainSyncLiveConversationEntries(newBuilder(), interval, false)
+	if err != nil {
+		t.Fatalf("unexpected error creating live entries: %s", err)
+	}
+	var headers []ledger.BlockHeader
+	var liveStart time.Time
+	syncEntries(
+		t,
+		entries,
+		len(chain)+liveCount,
+		func(header ledger.BlockHeader) {
+			headers = append(headers, header)
+			if len(headers) == len(chain) {
+				liveStart = time.Now()
+			}
+		},
+	)
+	// The blocks from the builder are followed by new blocks that extend the chain
+	for idx, header := range headers {
+		if idx < len(chain) && header.Hash() != hex.EncodeToString(chain[idx].Hash) {
+			t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, header.Hash(), chain[idx].Hash)
+		}
+		if header.SlotNumber() != uint64(idx) {
+			t.Fatalf("header %d did not have expected slot: got %d", idx, header.SlotNumber())
+		}
+		if idx > 0 && header.PrevHash() != headers[idx-1].Hash() {
+			t.Fatalf("header %d did not link to previous header", idx)
+		}
+		if idx >= len(chain) && header.Era().Id != ledger.EraIdShelley {
+			t.Fatalf("header %d did not have expected era: got %s", idx, header.Era().Name)
+		}
+	}
+	// The last new block is produced after the interval
+	if elapsed := time.Since(liveStart); elapsed < time.Duration(liveCount-1)*interval {
+		t.Fatalf("new blocks were produced too quickly: got %s, expected at least %s", elapsed, time.Duration(liveCount-1)*interval)
+	}
+}
+
+func BenchmarkChainSyncStream(b *testing.B) {
+	builder := blocks.NewMultiEraChainBuilder().
+		AddBlocks(ledger.EraIdConway, b.N)
+	b.ResetTimer()
+	syncStream(b, builder, b.N, nil)
+}
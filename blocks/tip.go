@@ -0,0 +1,165 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"fmt"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// TipTracker keeps track of the tip of the mocked node while building chainsync entries, so that the tip sent
+// with each message never goes backwards and is never behind the block being sent
+type TipTracker struct {
+	tip chainsync.Tip
+}
+
+// NewTipTracker returns a new TipTracker starting at the provided tip. Use ChainTip for a node that already has
+// the entire chain, or the origin for a node that produces the chain as it is served
+func NewTipTracker(tip chainsync.Tip) *TipTracker {
+	return &TipTracker{
+		tip: tip,
+	}
+}
+
+// Tip returns the current tip
+func (t *TipTracker) Tip() chainsync.Tip {
+	return t.tip
+}
+
+// SetTip sets the current tip, such as when the mocked node switches to a shorter fork
+func (t *TipTracker) SetTip(tip chainsync.Tip) {
+	t.tip = tip
+}
+
+// RollForwardNtN returns a conversation entry that sends a NtN chainsync RollForward for the provided block. The
+// tip is advanced to the block first if the block is past the current tip
+func (t *TipTracker) RollForwardNtN(block Block) ouroboros_mock.ConversationEntryOutput {
+	if tipIsOrigin(t.tip) || block.BlockNumber > t.tip.BlockNumber {
+		t.tip = block.Tip()
+	}
+	return NewChainSyncRollForwardNtNEntry(block, t.tip)
+}
+
+// RollBackwardNtN returns a conversation entry that sends a NtN chainsync RollBackward to the provided point with
+// the current tip
+func (t *TipTracker) RollBackwardNtN(point common.Point) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: chainsync.ProtocolIdNtN,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			chainsync.NewMsgRollBackward(point, t.tip),
+		},
+	}
+}
+
+// ValidateTips checks the tips in the chainsync messages sent by the provided conversation entries. The tip must
+// not go backwards, except when sending a RollBackward, and each block sent with RollForward must not be past the
+// tip. Only top-level output entries are checked
+func ValidateTips(entries []ouroboros_mock.ConversationEntry) error {
+	var prevTip *chainsync.Tip
+	for entryIdx, entry := range entries {
+		output, ok := entry.(ouroboros_mock.ConversationEntryOutput)
+		if !ok {
+			continue
+		}
+		if output.ProtocolId != chainsync.ProtocolIdNtN &&
+			output.ProtocolId != chainsync.ProtocolIdNtC {
+			continue
+		}
+		for _, msg := range output.Messages {
+			var tip chainsync.Tip
+			allowBackwards := false
+			switch msg := msg.(type) {
+			case *chainsync.MsgRollForwardNtN:
+				tip = msg.Tip
+				header, err := rollForwardNtNHeader(msg)
+				if err != nil {
+					return fmt.Errorf("entry %d: %w", entryIdx, err)
+				}
+				if header.BlockNumber() > tip.BlockNumber {
+					return fmt.Errorf(
+						"entry %d: RollForward for block %d is past the tip at block %d",
+						entryIdx,
+						header.BlockNumber(),
+						tip.BlockNumber,
+					)
+				}
+			case *chainsync.MsgRollForwardNtC:
+				tip = msg.Tip
+				block, err := ledger.NewBlockFromCbor(msg.BlockType(), msg.BlockCbor())
+				if err != nil {
+					return fmt.Errorf("entry %d: failed to decode block: %w", entryIdx, err)
+				}
+				if block.BlockNumber() > tip.BlockNumber {
+					return fmt.Errorf(
+						"entry %d: RollForward for block %d is past the tip at block %d",
+						entryIdx,
+						block.BlockNumber(),
+						tip.BlockNumber,
+					)
+				}
+			case *chainsync.MsgRollBackward:
+				tip = msg.Tip
+				allowBackwards = true
+			case *chainsync.MsgIntersectFound:
+				tip = msg.Tip
+			case *chainsync.MsgIntersectNotFound:
+				tip = msg.Tip
+			default:
+				continue
+			}
+			if prevTip != nil && !allowBackwards &&
+				tip.BlockNumber < prevTip.BlockNumber {
+				return fmt.Errorf(
+					"entry %d: tip went backwards from block %d to block %d",
+					entryIdx,
+					prevTip.BlockNumber,
+					tip.BlockNumber,
+				)
+			}
+			prevTip = &tip
+		}
+	}
+	return nil
+}
+
+// rollForwardNtNHeader decodes the header from a NtN RollForward message
+func rollForwardNtNHeader(
+	msg *chainsync.MsgRollForwardNtN,
+) (ledger.BlockHeader, error) {
+	blockType := ledger.BlockHeaderToBlockTypeMap[msg.WrappedHeader.Era]
+	if msg.WrappedHeader.Era == ledger.BlockHeaderTypeByron {
+		blockType = msg.WrappedHeader.ByronType()
+	}
+	header, err := ledger.NewBlockHeaderFromCbor(
+		blockType,
+		msg.WrappedHeader.HeaderCbor(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode header: %w", err)
+	}
+	return header, nil
+}
+
+// tipIsOrigin reports whether the provided tip points at the origin
+func tipIsOrigin(tip chainsync.Tip) bool {
+	return tip.Point.Slot == 0 && len(tip.Point.Hash) == 0
+}
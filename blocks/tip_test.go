@@ -0,0 +1,91 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"strings"
+	"testing"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+func TestTipTracker(t *testing.T) {
+	chain := buildTestChain(t)
+	tracker := blocks.NewTipTracker(
+		chainsync.Tip{Point: common.NewPointOrigin()},
+	)
+	var entries []ouroboros_mock.ConversationEntry
+	for idx, block := range chain {
+		entries = append(entries, tracker.RollForwardNtN(block))
+		if tracker.Tip().BlockNumber != block.BlockNumber {
+			t.Fatalf("tip did not advance to block %d: got block %d", idx, tracker.Tip().BlockNumber)
+		}
+	}
+	// Rolling back keeps the current tip
+	entries = append(entries, tracker.RollBackwardNtN(chain[2].Point()))
+	chainTip := blocks.ChainTip(chain)
+	if tracker.Tip().BlockNumber != chainTip.BlockNumber {
+		t.Fatalf("tip changed after rollback: %#v", tracker.Tip())
+	}
+	// Serving blocks again after the rollback doesn't move the tip backwards
+	entries = append(entries, tracker.RollForwardNtN(chain[3]))
+	if tracker.Tip().BlockNumber != chainTip.BlockNumber {
+		t.Fatalf("tip moved backwards: %#v", tracker.Tip())
+	}
+	if err := blocks.ValidateTips(entries); err != nil {
+		t.Fatalf("unexpected error validating tips: %s", err)
+	}
+}
+
+func TestValidateTips(t *testing.T) {
+	chain := buildTestChain(t)
+	if err := blocks.ValidateTips(blocks.ChainSyncNtNConversationEntries(chain)); err != nil {
+		t.Fatalf("unexpected error validating tips: %s", err)
+	}
+	testDefs := []struct {
+		name        string
+		entries     []ouroboros_mock.ConversationEntry
+		expectedErr string
+	}{
+		{
+			name: "block past tip",
+			entries: []ouroboros_mock.ConversationEntry{
+				blocks.NewChainSyncRollForwardNtNEntry(chain[5], chain[2].Tip()),
+			},
+			expectedErr: "entry 0: RollForward for block 5 is past the tip at block 2",
+		},
+		{
+			name: "tip goes backwards",
+			entries: []ouroboros_mock.ConversationEntry{
+				blocks.NewChainSyncRollForwardNtNEntry(chain[0], chain[4].Tip()),
+				blocks.NewChainSyncRollForwardNtNEntry(chain[1], chain[3].Tip()),
+			},
+			expectedErr: "entry 1: tip went backwards from block 4 to block 3",
+		},
+	}
+	for _, testDef := range testDefs {
+		err := blocks.ValidateTips(testDef.entries)
+		if err == nil {
+			t.Fatalf("%s: did not receive expected error", testDef.name)
+		}
+		if !strings.Contains(err.Error(), testDef.expectedErr) {
+			t.Fatalf("%s: did not receive expected error\n  got:    %s\n  wanted: %s", testDef.name, err, testDef.expectedErr)
+		}
+	}
+}
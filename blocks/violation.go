@@ -0,0 +1,49 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+)
+
+// NewChainSyncRollForwardBeforeIntersectConversation returns a chainsync conversation that sends a RollForward for
+// the provided block without waiting for the client to negotiate an intersection. The message is unsolicited while
+// the client has agency and isn't a valid reply to FindIntersect, so it violates the protocol state machine
+func NewChainSyncRollForwardBeforeIntersectConversation(
+	block Block,
+) ChainSyncConversation {
+	return ChainSyncConversation{
+		ChainSyncRollForward{
+			Block: block,
+			Tip:   block.Tip(),
+		},
+	}
+}
+
+// NewChainSyncRollBackwardForIntersectConversation returns a chainsync conversation that replies to FindIntersect
+// from a client with a RollBackward to the origin. Only IntersectFound and IntersectNotFound are valid replies to
+// FindIntersect, so the message violates the protocol state machine
+func NewChainSyncRollBackwardForIntersectConversation(
+	tip chainsync.Tip,
+) ChainSyncConversation {
+	return ChainSyncConversation{
+		ChainSyncFindIntersect{},
+		ChainSyncRollBackward{
+			Point: common.NewPointOrigin(),
+			Tip:   tip,
+		},
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"go.uber.org/goleak"
+)
+
+func TestChainSyncProtocolViolation(t *testing.T) {
+	chain := buildTestChain(t)
+	testDefs := []struct {
+		name         string
+		conversation blocks.ChainSyncConversation
+	}{
+		{
+			name:         "RollForwardBeforeIntersect",
+			conversation: blocks.NewChainSyncRollForwardBeforeIntersectConversation(chain[0]),
+		},
+		{
+			name:         "RollBackwardForIntersect",
+			conversation: blocks.NewChainSyncRollBackwardForIntersectConversation(blocks.ChainTip(chain)),
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				append(
+					[]ouroboros_mock.ConversationEntry{
+						ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+						ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+					},
+					testDef.conversation.Render(false)...,
+				),
+			)
+			oConn, err := ouroboros.New(
+				ouroboros.WithConnection(mockConn),
+				ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+				ouroboros.WithNodeToNode(true),
+				ouroboros.WithKeepAlive(false),
+				ouroboros.WithChainSyncConfig(
+					chainsync.NewConfig(
+						chainsync.WithRollBackwardFunc(
+							func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+								return nil
+							},
+						),
+						chainsync.WithRollForwardFunc(
+							func(chainsync.CallbackContext, uint, any, chainsync.Tip) error {
+								return nil
+							},
+						),
+					),
+				),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+			}
+			// The sync fails with the protocol error, which is checked below
+			go func() {
+				_ = oConn.ChainSync().Client.Sync(nil)
+			}()
+			select {
+			case err := <-oConn.ErrorChan():
+				if err == nil || !strings.Contains(err.Error(), "not allowed in current protocol state") {
+					t.Fatalf("did not receive expected error: got %v", err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("did not receive protocol violation error within timeout")
+			}
+			if err := oConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+			}
+		})
+	}
+}
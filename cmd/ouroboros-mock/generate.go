@@ -0,0 +1,172 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Output formats for generated chains
+const (
+	outputFormatCbor = "cbor"
+	outputFormatHex  = "hex"
+	outputFormatJson = "json"
+)
+
+// jsonBlock is the JSON representation of a generated block
+type jsonBlock struct {
+	Era         string `json:"era"`
+	BlockType   uint   `json:"blockType"`
+	BlockNumber uint64 `json:"blockNumber"`
+	Slot        uint64 `json:"slot"`
+	Hash        string `json:"hash"`
+	PrevHash    string `json:"prevHash"`
+	Cbor        string `json:"cbor"`
+}
+
+func runGenerate(args []string) error {
+	if len(args) < 1 {
+		return errors.New("no generate target specified, expected: chain")
+	}
+	switch args[0] {
+	case "chain":
+		return runGenerateChain(args[1:])
+	default:
+		return fmt.Errorf("unknown generate target: %s", args[0])
+	}
+}
+
+func runGenerateChain(args []string) error {
+	fs := flag.NewFlagSet("generate chain", flag.ContinueOnError)
+	eraName := fs.String("era", "conway", "era of the generated blocks")
+	blockCount := fs.Int("blocks", 10, "number of blocks to generate")
+	outFile := fs.String("out", "", "file to write the chain to (default stdout)")
+	format := fs.String(
+		"format",
+		outputFormatCbor,
+		"output format: cbor (array of [block type, block] pairs), hex (one block per line), or json",
+	)
+	seed := fs.Int64("seed", 0, "seed for the generated header fields")
+	startSlot := fs.Uint64("start-slot", 0, "slot of the first block")
+	slotInterval := fs.Uint64("slot-interval", 1, "number of slots between blocks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	eraId, err := eraIdByName(*eraName)
+	if err != nil {
+		return err
+	}
+	if *blockCount <= 0 {
+		return errors.New("number of blocks must be positive")
+	}
+	// Validate the format before creating the output file, which would otherwise be truncated
+	switch *format {
+	case outputFormatCbor, outputFormatHex, outputFormatJson:
+	default:
+		return fmt.Errorf("unknown output format: %s", *format)
+	}
+	chain, err := blocks.NewMultiEraChainBuilder(
+		blocks.WithRandomSeed(*seed),
+		blocks.WithStartSlot(*startSlot),
+		blocks.WithSlotInterval(*slotInterval),
+	).
+		AddBlocks(eraId, *blockCount).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build chain: %w", err)
+	}
+	if *outFile == "" {
+		return writeChain(os.Stdout, chain, *format)
+	}
+	f, err := os.Create(*outFile)
+	if err != nil {
+		return err
+	}
+	if err := writeChain(f, chain, *format); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// eraIdByName returns the era ID for the provided era name, ignoring case
+func eraIdByName(name string) (uint, error) {
+	for eraId := uint(ledger.EraIdByron); eraId <= ledger.EraIdConway; eraId++ {
+		if strings.EqualFold(ledger.GetEraById(uint8(eraId)).Name, name) {
+			return eraId, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown era: %s", name)
+}
+
+// writeChain writes the provided chain in the specified output format
+func writeChain(out io.Writer, chain []blocks.Block, format string) error {
+	switch format {
+	case outputFormatCbor:
+		tmpData := make([]any, 0, len(chain))
+		for _, block := range chain {
+			tmpData = append(
+				tmpData,
+				[]any{block.BlockType, cbor.RawMessage(block.Cbor)},
+			)
+		}
+		data, err := cbor.Encode(tmpData)
+		if err != nil {
+			return fmt.Errorf("failed to encode chain: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	case outputFormatHex:
+		for _, block := range chain {
+			if _, err := fmt.Fprintln(out, hex.EncodeToString(block.Cbor)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputFormatJson:
+		tmpData := make([]jsonBlock, 0, len(chain))
+		for _, block := range chain {
+			tmpData = append(
+				tmpData,
+				jsonBlock{
+					Era:         ledger.GetEraById(uint8(block.EraId)).Name,
+					BlockType:   block.BlockType,
+					BlockNumber: block.BlockNumber,
+					Slot:        block.Slot,
+					Hash:        hex.EncodeToString(block.Hash),
+					PrevHash:    hex.EncodeToString(block.PrevHash),
+					Cbor:        hex.EncodeToString(block.Cbor),
+				},
+			)
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tmpData)
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
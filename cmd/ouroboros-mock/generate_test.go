@@ -0,0 +1,164 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+const (
+	testBlockCount   = 5
+	testStartSlot    = 100
+	testSlotInterval = 2
+)
+
+func TestGenerateChain(t *testing.T) {
+	testDefs := []struct {
+		format string
+		decode func(t *testing.T, data []byte) []ledger.Block
+	}{
+		{format: outputFormatCbor, decode: decodeCborChain},
+		{format: outputFormatHex, decode: decodeHexChain},
+		{format: outputFormatJson, decode: decodeJsonChain},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.format, func(t *testing.T) {
+			outFile := filepath.Join(t.TempDir(), "chain")
+			err := runGenerateChain(
+				[]string{
+					"-era", "conway",
+					"-blocks", strconv.Itoa(testBlockCount),
+					"-start-slot", strconv.Itoa(testStartSlot),
+					"-slot-interval", strconv.Itoa(testSlotInterval),
+					"-format", testDef.format,
+					"-out", outFile,
+				},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error generating chain: %s", err)
+			}
+			data, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("unexpected error reading output: %s", err)
+			}
+			chain := testDef.decode(t, data)
+			if len(chain) != testBlockCount {
+				t.Fatalf("did not get expected number of blocks: got %d, expected %d", len(chain), testBlockCount)
+			}
+			for idx, block := range chain {
+				expectedSlot := uint64(testStartSlot + idx*testSlotInterval)
+				if block.SlotNumber() != expectedSlot {
+					t.Fatalf("did not get expected slot for block %d: got %d, expected %d", idx, block.SlotNumber(), expectedSlot)
+				}
+				if idx > 0 && block.PrevHash() != chain[idx-1].Hash() {
+					t.Fatalf("block %d does not link to the previous block", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateChainUnknownFormat(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "chain")
+	if err := os.WriteFile(outFile, []byte("existing"), 0o600); err != nil {
+		t.Fatalf("unexpected error writing file: %s", err)
+	}
+	err := runGenerateChain([]string{"-format", "yaml", "-out", outFile})
+	if err == nil {
+		t.Fatalf("did not get expected error")
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("unexpected error reading file: %s", err)
+	}
+	if string(data) != "existing" {
+		t.Fatalf("output file was modified: %q", data)
+	}
+}
+
+func decodeCborChain(t *testing.T, data []byte) []ledger.Block {
+	t.Helper()
+	var tmpData []struct {
+		cbor.StructAsArray
+		BlockType uint
+		Block     cbor.RawMessage
+	}
+	if _, err := cbor.Decode(data, &tmpData); err != nil {
+		t.Fatalf("unexpected error decoding chain: %s", err)
+	}
+	ret := make([]ledger.Block, 0, len(tmpData))
+	for _, item := range tmpData {
+		ret = append(ret, decodeBlock(t, item.BlockType, item.Block))
+	}
+	return ret
+}
+
+func decodeHexChain(t *testing.T, data []byte) []ledger.Block {
+	t.Helper()
+	var ret []ledger.Block
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		blockCbor, err := hex.DecodeString(scanner.Text())
+		if err != nil {
+			t.Fatalf("unexpected error decoding hex: %s", err)
+		}
+		ret = append(ret, decodeBlock(t, ledger.BlockTypeConway, blockCbor))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error reading lines: %s", err)
+	}
+	return ret
+}
+
+func decodeJsonChain(t *testing.T, data []byte) []ledger.Block {
+	t.Helper()
+	var tmpData []jsonBlock
+	if err := json.Unmarshal(data, &tmpData); err != nil {
+		t.Fatalf("unexpected error decoding JSON: %s", err)
+	}
+	ret := make([]ledger.Block, 0, len(tmpData))
+	for _, item := range tmpData {
+		blockCbor, err := hex.DecodeString(item.Cbor)
+		if err != nil {
+			t.Fatalf("unexpected error decoding hex: %s", err)
+		}
+		block := decodeBlock(t, item.BlockType, blockCbor)
+		if item.Slot != block.SlotNumber() || item.Hash != block.Hash() {
+			t.Fatalf("JSON fields do not match block: got slot %d and hash %s", item.Slot, item.Hash)
+		}
+		ret = append(ret, block)
+	}
+	return ret
+}
+
+func decodeBlock(t *testing.T, blockType uint, data []byte) ledger.Block {
+	t.Helper()
+	block, err := ledger.NewBlockFromCbor(blockType, data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding block: %s", err)
+	}
+	return block
+}
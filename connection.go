@@ -16,16 +16,27 @@ package ouroboros_mock
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"reflect"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/handshake"
 )
 
+// MaxPendingInput is the maximum number of messages received from the client that can be buffered while waiting
+// for an entry that processes them
+const MaxPendingInput = 1024
+
 // ProtocolRole is an enum of the protocol roles
 type ProtocolRole uint
 
@@ -38,6 +49,7 @@ const (
 
 // Connection mocks an Ouroboros connection
 type Connection struct {
+	protocolRole  ProtocolRole
 	mockConn      net.Conn
 	conn          net.Conn
 	conversation  []ConversationEntry
@@ -46,24 +58,117 @@ type Connection struct {
 	doneChan      chan any
 	onceClose     sync.Once
 	errorChan     chan error
+	errorMutex    sync.Mutex
+	errorClosed   bool
+	bearer        bearerConfig
+	onMismatch    MismatchFunc
+	interleaved   bool
+	inputMutex    sync.Mutex
+	inputCond     *sync.Cond
+	inputReading  bool
+	inputClosed   bool
+	pendingInput  []*muxer.Segment
+	parallelDepth int
+	loopDepth     int
+	loopEnded     bool
+	onEntry       EntryFunc
+	stats         connectionStats
+	tracer        *tracer
+	timing        timingState
+	ctx           context.Context
+	readTimeout   time.Duration
+	writeTimeout  time.Duration
+	maxSegment    int
+	sendMutex     sync.Mutex
 }
 
-// NewConnection returns a new Connection with the provided conversation entries
+// NewConnection returns a new Connection with the provided conversation entries and options
 func NewConnection(
 	protocolRole ProtocolRole,
 	conversation []ConversationEntry,
+	opts ...ConnectionOptionFunc,
 ) net.Conn {
+	c := newConnection(protocolRole, conversation, opts...)
+	var mockConn net.Conn
+	c.conn, mockConn = net.Pipe()
+	c.start(mockConn)
+	return c
+}
+
+// newConnection returns a new Connection that hasn't been started
+func newConnection(
+	protocolRole ProtocolRole,
+	conversation []ConversationEntry,
+	opts ...ConnectionOptionFunc,
+) *Connection {
 	c := &Connection{
+		protocolRole: protocolRole,
 		conversation: conversation,
 		doneChan:     make(chan any),
 		errorChan:    make(chan error, 1),
 	}
-	c.conn, c.mockConn = net.Pipe()
+	c.timing.scale = 1
+	c.inputCond = sync.NewCond(&c.inputMutex)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithInterleavedProtocols allows messages from the client for other mini-protocols to arrive before the message
+// expected by an input entry. Ordering is only enforced within each mini-protocol. Messages are buffered until an
+// input entry for their mini-protocol is reached, and the conversation fails if more than MaxPendingInput messages
+// are buffered, such as when the client keeps sending messages on a mini-protocol with no remaining entries
+func WithInterleavedProtocols() ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.interleaved = true
+	}
+}
+
+// WithContext specifies a context for the connection. The connection is closed when the context is done, which
+// stops the conversation and interrupts any pending reads, writes, and sleeps
+func WithContext(ctx context.Context) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.ctx = ctx
+	}
+}
+
+// WithReadTimeout specifies the maximum time to wait for a message from the client. The conversation fails with an
+// error if no message arrives in time
+func WithReadTimeout(timeout time.Duration) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout specifies the maximum time to wait for the client to accept each segment sent. The conversation
+// fails with an error if a segment isn't accepted in time
+func WithWriteTimeout(timeout time.Duration) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.writeTimeout = timeout
+	}
+}
+
+// WithMaxSegmentSize specifies the maximum payload size for segments sent to the client. Larger payloads are split
+// across multiple segments. The default and upper limit is the maximum muxer segment payload size
+func WithMaxSegmentSize(size int) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.maxSegment = size
+	}
+}
+
+// start runs the conversation over the provided mocked side of the connection
+func (c *Connection) start(mockConn net.Conn) {
+	c.mockConn = mockConn
+	// Simulate the network bearer in both directions on the mocked side of the connection
+	if c.bearer.enabled() {
+		c.mockConn = newBearerConn(c.mockConn, &c.bearer)
+	}
 	// Start a muxer on the mocked side of the connection
 	c.muxer = muxer.New(c.mockConn)
 	// The muxer is for the opposite end of the connection, so we flip the protocol role
 	muxerProtocolRole := muxer.ProtocolRoleResponder
-	if protocolRole == ProtocolRoleServer {
+	if c.protocolRole == ProtocolRoleServer {
 		muxerProtocolRole = muxer.ProtocolRoleInitiator
 	}
 	// We use ProtocolUnknown to catch all inbound messages when no other protocols are registered
@@ -78,12 +183,29 @@ func NewConnection(
 		if !ok {
 			return
 		}
-		c.errorChan <- fmt.Errorf("muxer error: %w", err)
-		c.Close()
+		// The client disconnecting is the expected way for a loop to end
+		if errors.Is(err, io.EOF) {
+			c.inputMutex.Lock()
+			looping := c.loopDepth > 0 || c.loopEnded
+			c.inputMutex.Unlock()
+			if looping {
+				return
+			}
+		}
+		c.sendError(fmt.Errorf("muxer error: %w", err))
 	}()
+	// Close the connection when the context is done
+	if c.ctx != nil {
+		go func() {
+			select {
+			case <-c.ctx.Done():
+				_ = c.Close()
+			case <-c.doneChan:
+			}
+		}()
+	}
 	// Start async conversation handler
 	go c.asyncLoop()
-	return c
 }
 
 func (c *Connection) ErrorChan() <-chan error {
@@ -106,9 +228,12 @@ func (c *Connection) Close() error {
 	c.onceClose.Do(func() {
 		close(c.doneChan)
 		c.muxer.Stop()
-		if err := c.conn.Close(); err != nil {
-			retErr = err
-			return
+		// There's no client side when the conversation runs over a real connection
+		if c.conn != nil {
+			if err := c.conn.Close(); err != nil {
+				retErr = err
+				return
+			}
 		}
 		if err := c.mockConn.Close(); err != nil {
 			retErr = err
@@ -148,38 +273,106 @@ func (c *Connection) SetWriteDeadline(t time.Time) error {
 }
 
 func (c *Connection) sendError(err error) {
+	c.errorMutex.Lock()
+	// Discard errors that occur after the conversation has finished
+	if c.errorClosed {
+		c.errorMutex.Unlock()
+		return
+	}
 	select {
 	case c.errorChan <- err:
-		_ = c.Close()
 	default:
 	}
+	c.errorMutex.Unlock()
+	_ = c.Close()
 }
 
 func (c *Connection) asyncLoop() {
 	defer func() {
+		c.errorMutex.Lock()
+		c.errorClosed = true
 		close(c.errorChan)
+		c.errorMutex.Unlock()
 	}()
-	for _, entry := range c.conversation {
+	c.runConversation(c.conversation)
+}
+
+// runConversation processes the provided conversation entries in order. It returns false if the conversation
+// should not continue
+func (c *Connection) runConversation(conversation []ConversationEntry) bool {
+	for _, entry := range conversation {
 		select {
 		case <-c.doneChan:
-			return
+			return false
 		default:
 		}
 		switch entry := entry.(type) {
 		case ConversationEntryInput:
 			if err := c.processInputEntry(entry); err != nil {
 				c.sendError(fmt.Errorf("input error: %w", err))
-				return
+				return false
 			}
 		case ConversationEntryOutput:
 			if err := c.processOutputEntry(entry); err != nil {
 				c.sendError(fmt.Errorf("output error: %w", err))
-				return
+				return false
+			}
+		case ConversationEntryFaultyOutput:
+			if err := c.processFaultyOutputEntry(entry); err != nil {
+				c.sendError(fmt.Errorf("output error: %w", err))
+				return false
 			}
 		case ConversationEntryClose:
 			c.Close()
 		case ConversationEntrySleep:
-			time.Sleep(entry.Duration)
+			// Stop sleeping early if the connection is closed
+			if !c.sleep(c.scaleDuration(entry.Duration)) {
+				return false
+			}
+		case ConversationEntryStall:
+			if err := c.processStallEntry(entry); err != nil {
+				c.sendError(fmt.Errorf("stall error: %w", err))
+				return false
+			}
+		case ConversationEntryHandshakeNegotiate:
+			if err := c.processHandshakeNegotiateEntry(entry); err != nil {
+				c.sendError(fmt.Errorf("handshake error: %w", err))
+				return false
+			}
+		case ConversationEntryBranch:
+			branch, err := c.processBranchEntry(entry)
+			if err != nil {
+				c.sendError(fmt.Errorf("branch error: %w", err))
+				return false
+			}
+			if !c.runConversation(branch) {
+				return false
+			}
+		case ConversationEntryResponder:
+			entries, err := c.processResponderEntry(entry)
+			if err != nil {
+				c.sendError(fmt.Errorf("responder error: %w", err))
+				return false
+			}
+			if !c.runConversation(entries) {
+				return false
+			}
+		case ConversationEntryParallel:
+			if !c.processParallelEntry(entry) {
+				return false
+			}
+		case ConversationEntryLoop:
+			if !c.processLoopEntry(entry) {
+				return false
+			}
+		case ConversationEntryStream:
+			if !c.processStreamEntry(entry) {
+				return false
+			}
+		case ConversationEntryResetAfterMessages:
+			c.processResetAfterMessagesEntry(entry)
+			c.entryProcessed(entry)
+			return false
 		default:
 			c.sendError(
 				fmt.Errorf(
@@ -188,37 +381,55 @@ func (c *Connection) asyncLoop() {
 					entry,
 				),
 			)
-			return
+			return false
 		}
+		c.entryProcessed(entry)
 	}
+	return true
 }
 
 func (c *Connection) processInputEntry(entry ConversationEntryInput) error {
-	// Wait for segment to be received from muxer
-	segment, ok := <-c.muxerRecvChan
-	if !ok {
-		return nil
+	segment, err := c.receiveSegment(entry.ProtocolId, entry.IsResponse)
+	if err != nil {
+		return err
 	}
-	if segment.GetProtocolId() != entry.ProtocolId {
-		return fmt.Errorf(
-			"input message protocol ID did not match expected value: expected %d, got %d",
-			entry.ProtocolId,
-			segment.GetProtocolId(),
-		)
+	if segment == nil {
+		return nil
 	}
-	if segment.IsResponse() != entry.IsResponse {
-		return fmt.Errorf(
-			"input message response flag did not match expected value: expected %v, got %v",
-			entry.IsResponse,
-			segment.IsResponse(),
-		)
+	if entry.MaxQueued > 0 {
+		if queued := 1 + c.queuedInput(entry.ProtocolId); queued > entry.MaxQueued {
+			return fmt.Errorf(
+				"received %d unprocessed messages, more than the maximum of %d",
+				queued,
+				entry.MaxQueued,
+			)
+		}
 	}
 	// Determine message type
 	msgType, err := cbor.DecodeIdFromList(segment.Payload)
 	if err != nil {
 		return fmt.Errorf("decode error: %s", err)
 	}
-	if entry.Message != nil {
+	if entry.Matcher != nil {
+		// Create Message object from CBOR
+		msg, err := entry.MsgFromCborFunc(uint(msgType), segment.Payload)
+		if err != nil {
+			return fmt.Errorf("message from CBOR error: %s", err)
+		}
+		if msg == nil {
+			return fmt.Errorf("received unknown message type: %d", msgType)
+		}
+		if !entry.Matcher(msg) {
+			return c.inputMismatch(
+				&InputMismatchError{
+					ProtocolId: entry.ProtocolId,
+					ActualType: uint(msgType),
+					Actual:     msg,
+					ActualCbor: segment.Payload,
+				},
+			)
+		}
+	} else if entry.Message != nil {
 		// Create Message object from CBOR
 		msg, err := entry.MsgFromCborFunc(uint(msgType), segment.Payload)
 		if err != nil {
@@ -234,22 +445,335 @@ func (c *Connection) processInputEntry(entry ConversationEntryInput) error {
 		// CBOR of the received message
 		msg.SetCbor(nil)
 		if !reflect.DeepEqual(msg, entry.Message) {
-			return fmt.Errorf(
-				"parsed message does not match expected value: got %#v, expected %#v",
-				msg,
-				entry.Message,
+			return c.inputMismatch(
+				&InputMismatchError{
+					ProtocolId:   entry.ProtocolId,
+					ExpectedType: uint(entry.Message.Type()),
+					ActualType:   uint(msgType),
+					Expected:     entry.Message,
+					Actual:       msg,
+					ExpectedCbor: messageCbor(entry.Message),
+					ActualCbor:   segment.Payload,
+				},
 			)
 		}
 	} else {
 		if entry.MessageType == uint(msgType) {
 			return nil
 		}
-		return fmt.Errorf("input message is not of expected type: expected %d, got %d", entry.MessageType, msgType)
+		return c.inputMismatch(
+			&InputMismatchError{
+				ProtocolId:   entry.ProtocolId,
+				ExpectedType: entry.MessageType,
+				ActualType:   uint(msgType),
+				ActualCbor:   segment.Payload,
+			},
+		)
 	}
 	return nil
 }
 
+// receiveSegment waits for the next segment from the client and checks that it has the expected protocol ID and
+// response flag. It returns a nil segment if the muxer has shut down
+func (c *Connection) receiveSegment(
+	protocolId uint16,
+	isResponse bool,
+) (*muxer.Segment, error) {
+	// Wait for segment to be received from muxer
+	segment, ok := c.nextSegment(protocolId)
+	if !ok {
+		return nil, nil
+	}
+	if segment.GetProtocolId() != protocolId {
+		return nil, fmt.Errorf(
+			"input message protocol ID did not match expected value: expected %d, got %d",
+			protocolId,
+			segment.GetProtocolId(),
+		)
+	}
+	if segment.IsResponse() != isResponse {
+		return nil, fmt.Errorf(
+			"input message response flag did not match expected value: expected %v, got %v",
+			isResponse,
+			segment.IsResponse(),
+		)
+	}
+	return segment, nil
+}
+
+// receiveMessage waits for the next message from the client, checks that it has the expected protocol ID and
+// response flag, and decodes it using the provided function. It returns a nil message if the muxer has shut down
+func (c *Connection) receiveMessage(
+	protocolId uint16,
+	isResponse bool,
+	msgFromCborFunc protocol.MessageFromCborFunc,
+) (protocol.Message, error) {
+	segment, err := c.receiveSegment(protocolId, isResponse)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return nil, nil
+	}
+	msgType, err := cbor.DecodeIdFromList(segment.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err)
+	}
+	msg, err := msgFromCborFunc(uint(msgType), segment.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("message from CBOR error: %s", err)
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("received unknown message type: %d", msgType)
+	}
+	return msg, nil
+}
+
+// processBranchEntry matches a message from the client and returns the entries for the selected branch
+func (c *Connection) processBranchEntry(
+	entry ConversationEntryBranch,
+) ([]ConversationEntry, error) {
+	msg, err := c.receiveMessage(
+		entry.ProtocolId,
+		entry.IsResponse,
+		entry.MsgFromCborFunc,
+	)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	if entry.Predicate(msg) {
+		return entry.Then, nil
+	}
+	return entry.Else, nil
+}
+
+// processResponderEntry matches a message from the client and returns the entries generated for it
+func (c *Connection) processResponderEntry(
+	entry ConversationEntryResponder,
+) ([]ConversationEntry, error) {
+	msg, err := c.receiveMessage(
+		entry.ProtocolId,
+		entry.IsResponse,
+		entry.MsgFromCborFunc,
+	)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	return entry.ResponseFunc(msg)
+}
+
+// nextSegment returns the next segment received from the muxer. When protocols are interleaved or parallel
+// conversations are running, segments for other protocols are set aside until an entry for that protocol is
+// processed
+func (c *Connection) nextSegment(protocolId uint16) (*muxer.Segment, bool) {
+	return c.waitSegment(
+		func(segment *muxer.Segment, routed bool) bool {
+			return !routed || segment.GetProtocolId() == protocolId
+		},
+	)
+}
+
+// nextAnySegment returns the next segment received from the muxer, regardless of protocol
+func (c *Connection) nextAnySegment() (*muxer.Segment, bool) {
+	return c.waitSegment(
+		func(*muxer.Segment, bool) bool {
+			return true
+		},
+	)
+}
+
+// waitSegment returns the first received segment accepted by the provided function, waiting for more segments
+// from the muxer as needed. This is safe to call from multiple goroutines, with only one reading from the muxer
+// at a time
+func (c *Connection) waitSegment(
+	accept func(segment *muxer.Segment, routed bool) bool,
+) (*muxer.Segment, bool) {
+	c.inputMutex.Lock()
+	defer c.inputMutex.Unlock()
+	for {
+		routed := c.interleaved || c.parallelDepth > 0
+		for idx, segment := range c.pendingInput {
+			if accept(segment, routed) {
+				c.pendingInput = slices.Delete(c.pendingInput, idx, idx+1)
+				return segment, true
+			}
+		}
+		if c.inputClosed {
+			return nil, false
+		}
+		// Wait for another goroutine that is already reading from the muxer
+		if c.inputReading {
+			c.inputCond.Wait()
+			continue
+		}
+		c.inputReading = true
+		c.inputMutex.Unlock()
+		segment, ok, timedOut := c.readMuxerSegment()
+		c.inputMutex.Lock()
+		c.inputReading = false
+		if timedOut {
+			c.inputClosed = true
+			c.inputCond.Broadcast()
+			c.sendError(
+				fmt.Errorf(
+					"read timeout: no message received within %s",
+					c.readTimeout,
+				),
+			)
+			return nil, false
+		}
+		if ok {
+			c.segmentReceived(segment)
+			// Clients may send multiple messages in a single segment, such as when pipelining requests
+			for _, msgSegment := range splitSegment(segment) {
+				c.messageReceived(msgSegment.GetProtocolId(), msgSegment.Payload)
+				c.trace(TraceDirectionIn, msgSegment.GetProtocolId(), msgSegment.Payload)
+				c.pendingInput = append(c.pendingInput, msgSegment)
+			}
+			if len(c.pendingInput) > MaxPendingInput {
+				c.inputClosed = true
+				c.inputCond.Broadcast()
+				c.sendError(
+					fmt.Errorf(
+						"too many unprocessed messages: more than %d messages received without a matching entry",
+						MaxPendingInput,
+					),
+				)
+				return nil, false
+			}
+		} else {
+			c.inputClosed = true
+		}
+		c.inputCond.Broadcast()
+	}
+}
+
+// queuedInput returns the number of segments for the provided protocol that have been received from the muxer
+// but not yet processed
+func (c *Connection) queuedInput(protocolId uint16) int {
+	c.inputMutex.Lock()
+	defer c.inputMutex.Unlock()
+	var ret int
+	for _, segment := range c.pendingInput {
+		if segment.GetProtocolId() == protocolId {
+			ret++
+		}
+	}
+	return ret
+}
+
+// readMuxerSegment waits for the next segment from the muxer, up to the read timeout if one is configured. It
+// returns false if the muxer has shut down, and true for the last value if the read timed out
+func (c *Connection) readMuxerSegment() (*muxer.Segment, bool, bool) {
+	if c.readTimeout <= 0 {
+		segment, ok := <-c.muxerRecvChan
+		return segment, ok, false
+	}
+	timer := time.NewTimer(c.readTimeout)
+	defer timer.Stop()
+	select {
+	case segment, ok := <-c.muxerRecvChan:
+		return segment, ok, false
+	case <-timer.C:
+		return nil, false, true
+	}
+}
+
+// splitSegment returns a segment for each message in the provided segment. The remainder of the payload is
+// returned as-is if it can't be decoded
+func splitSegment(segment *muxer.Segment) []*muxer.Segment {
+	var ret []*muxer.Segment
+	payload := segment.Payload
+	for len(payload) > 0 {
+		var tmpMsg cbor.RawMessage
+		msgLen, err := cbor.Decode(payload, &tmpMsg)
+		if err != nil || msgLen == 0 || msgLen == len(payload) {
+			break
+		}
+		ret = append(
+			ret,
+			muxer.NewSegment(
+				segment.GetProtocolId(),
+				payload[:msgLen],
+				segment.IsResponse(),
+			),
+		)
+		payload = payload[msgLen:]
+	}
+	if len(ret) == 0 {
+		return []*muxer.Segment{segment}
+	}
+	return append(
+		ret,
+		muxer.NewSegment(
+			segment.GetProtocolId(),
+			payload,
+			segment.IsResponse(),
+		),
+	)
+}
+
+// inputMismatch passes the provided mismatch to the mismatch function, if any, and returns it as an error
+func (c *Connection) inputMismatch(mismatchErr *InputMismatchError) error {
+	if c.onMismatch != nil {
+		c.onMismatch(mismatchErr)
+	}
+	return mismatchErr
+}
+
 func (c *Connection) processOutputEntry(entry ConversationEntryOutput) error {
+	// The conversation stops at the next entry if the connection is closed while waiting
+	if !c.waitOutputTiming(entry) {
+		return nil
+	}
+	entry, err := resolveOutputMessages(entry)
+	if err != nil {
+		return err
+	}
+	payload, err := encodeOutputPayload(entry)
+	if err != nil {
+		return err
+	}
+	return c.sendPayload(entry, payload)
+}
+
+func (c *Connection) processFaultyOutputEntry(
+	entry ConversationEntryFaultyOutput,
+) error {
+	if !c.waitOutputTiming(entry.Output) {
+		return nil
+	}
+	output, err := resolveOutputMessages(entry.Output)
+	if err != nil {
+		return err
+	}
+	payload, err := encodeOutputPayload(output)
+	if err != nil {
+		return err
+	}
+	if entry.Mutator != nil {
+		payload = entry.Mutator(payload)
+	}
+	return c.sendPayload(output, payload)
+}
+
+// resolveOutputMessages returns a copy of the output entry with the messages from MessagesFunc, if set
+func resolveOutputMessages(
+	entry ConversationEntryOutput,
+) (ConversationEntryOutput, error) {
+	if entry.MessagesFunc == nil {
+		return entry, nil
+	}
+	msgs, err := entry.MessagesFunc()
+	if err != nil {
+		return entry, err
+	}
+	entry.Messages = msgs
+	return entry, nil
+}
+
+// encodeOutputPayload returns the serialized messages from an output entry
+func encodeOutputPayload(entry ConversationEntryOutput) ([]byte, error) {
 	payloadBuf := bytes.NewBuffer(nil)
 	for _, msg := range entry.Messages {
 		// Get raw CBOR from message
@@ -259,22 +783,244 @@ func (c *Connection) processOutputEntry(entry ConversationEntryOutput) error {
 			var err error
 			data, err = cbor.Encode(msg)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		payloadBuf.Write(data)
 	}
-	segment := muxer.NewSegment(
-		entry.ProtocolId,
-		payloadBuf.Bytes(),
-		entry.IsResponse,
+	return payloadBuf.Bytes(), nil
+}
+
+// sendPayload sends the provided payload using the protocol ID and response flag from an output entry. The payload
+// is split across multiple segments if it's larger than the maximum segment size
+func (c *Connection) sendPayload(
+	entry ConversationEntryOutput,
+	payload []byte,
+) error {
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+	if c.writeTimeout > 0 {
+		if err := c.mockConn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return err
+		}
+		defer func() {
+			_ = c.mockConn.SetWriteDeadline(time.Time{})
+		}()
+	}
+	maxSegment := muxer.SegmentMaxPayloadLength
+	if c.maxSegment > 0 {
+		maxSegment = min(c.maxSegment, maxSegment)
+	}
+	remaining := payload
+	segmentCount := 0
+	for {
+		chunk := remaining[:min(len(remaining), maxSegment)]
+		remaining = remaining[len(chunk):]
+		segment := muxer.NewSegment(
+			entry.ProtocolId,
+			chunk,
+			entry.IsResponse,
+		)
+		if err := c.muxer.Send(segment); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return fmt.Errorf(
+					"write timeout: segment not accepted within %s",
+					c.writeTimeout,
+				)
+			}
+			return err
+		}
+		segmentCount++
+		if len(remaining) == 0 {
+			break
+		}
+	}
+	c.markSent()
+	c.payloadSent(entry.ProtocolId, entry.Messages, payload, segmentCount)
+	c.trace(TraceDirectionOut, entry.ProtocolId, payload)
+	return nil
+}
+
+func (c *Connection) processHandshakeNegotiateEntry(
+	entry ConversationEntryHandshakeNegotiate,
+) error {
+	msg, err := c.receiveMessage(
+		handshake.ProtocolId,
+		false,
+		handshake.NewMsgFromCbor,
+	)
+	if err != nil || msg == nil {
+		return err
+	}
+	msgProposeVersions, ok := msg.(*handshake.MsgProposeVersions)
+	if !ok {
+		return fmt.Errorf("input message is not of expected type: expected %d, got %d", handshake.MessageTypeProposeVersions, msg.Type())
+	}
+	versions := entry.Versions
+	if versions == nil {
+		versions = mockProtocolVersionMap()
+	}
+	// Find the highest mutually supported version
+	var acceptVersion uint16
+	var found bool
+	for version := range msgProposeVersions.VersionMap {
+		if _, ok := versions[version]; !ok {
+			continue
+		}
+		if !found || version > acceptVersion {
+			acceptVersion = version
+			found = true
+		}
+	}
+	var respMsg protocol.Message
+	if found && handshakeQueryRequested(acceptVersion, msgProposeVersions.VersionMap[acceptVersion]) {
+		queryVersions := entry.QueryVersions
+		if queryVersions == nil {
+			queryVersions = versions
+		}
+		respMsg = NewMsgHandshakeQueryReply(queryVersions)
+	} else if found {
+		respMsg = handshake.NewMsgAcceptVersion(
+			acceptVersion,
+			versions[acceptVersion],
+		)
+	} else {
+		supportedVersions := make([]uint16, 0, len(versions))
+		for version := range versions {
+			supportedVersions = append(supportedVersions, version)
+		}
+		slices.Sort(supportedVersions)
+		respMsg = handshake.NewMsgRefuse(
+			[]any{
+				handshake.RefuseReasonVersionMismatch,
+				supportedVersions,
+			},
+		)
+	}
+	return c.processOutputEntry(
+		ConversationEntryOutput{
+			ProtocolId: handshake.ProtocolId,
+			IsResponse: true,
+			Messages:   []protocol.Message{respMsg},
+		},
+	)
+}
+
+// mockProtocolVersionMap returns all NtC and NtN versions supported by gouroboros with the mock network magic
+func mockProtocolVersionMap() protocol.ProtocolVersionMap {
+	ret := protocol.GetProtocolVersionMap(
+		protocol.ProtocolModeNodeToClient,
+		MockNetworkMagic,
+		protocol.DiffusionModeInitiatorOnly,
+		false,
+		protocol.QueryModeDisabled,
+	)
+	ntnVersions := protocol.GetProtocolVersionMap(
+		protocol.ProtocolModeNodeToNode,
+		MockNetworkMagic,
+		protocol.DiffusionModeInitiatorOnly,
+		false,
+		protocol.QueryModeDisabled,
 	)
-	if err := c.muxer.Send(segment); err != nil {
+	for version, versionData := range ntnVersions {
+		ret[version] = versionData
+	}
+	return ret
+}
+
+// processParallelEntry runs each of the conversations from the entry concurrently and waits for them to finish.
+// It returns false if any of the conversations should not continue
+func (c *Connection) processParallelEntry(entry ConversationEntryParallel) bool {
+	c.inputMutex.Lock()
+	c.parallelDepth++
+	c.inputMutex.Unlock()
+	defer func() {
+		c.inputMutex.Lock()
+		c.parallelDepth--
+		c.inputMutex.Unlock()
+	}()
+	results := make([]bool, len(entry.Conversations))
+	var wg sync.WaitGroup
+	for idx, conversation := range entry.Conversations {
+		wg.Add(1)
+		go func(idx int, conversation []ConversationEntry) {
+			defer wg.Done()
+			results[idx] = c.runConversation(conversation)
+		}(idx, conversation)
+	}
+	wg.Wait()
+	return !slices.Contains(results, false)
+}
+
+// processLoopEntry runs the loop entries until the client disconnects. It returns false if the conversation
+// should not continue
+func (c *Connection) processLoopEntry(entry ConversationEntryLoop) bool {
+	c.inputMutex.Lock()
+	c.loopDepth++
+	c.inputMutex.Unlock()
+	defer func() {
+		c.inputMutex.Lock()
+		c.loopDepth--
+		c.inputMutex.Unlock()
+	}()
+	for {
+		if !c.runConversation(entry.Entries) {
+			return false
+		}
+		c.inputMutex.Lock()
+		inputClosed := c.inputClosed
+		if inputClosed {
+			c.loopEnded = true
+		}
+		c.inputMutex.Unlock()
+		if inputClosed {
+			return true
+		}
+	}
+}
+
+// processStreamEntry runs the batches of entries from the stream until it's finished. It returns false if the
+// conversation should not continue
+func (c *Connection) processStreamEntry(entry ConversationEntryStream) bool {
+	for {
+		entries, err := entry.NextFunc()
+		if err != nil {
+			c.sendError(fmt.Errorf("stream error: %w", err))
+			return false
+		}
+		if len(entries) == 0 {
+			return true
+		}
+		if !c.runConversation(entries) {
+			return false
+		}
+	}
+}
+
+func (c *Connection) processStallEntry(entry ConversationEntryStall) error {
+	if _, err := c.receiveSegment(entry.ProtocolId, entry.IsResponse); err != nil {
 		return err
 	}
+	if entry.Duration > 0 {
+		c.sleep(c.scaleDuration(entry.Duration))
+		return nil
+	}
+	<-c.doneChan
 	return nil
 }
 
+func (c *Connection) processResetAfterMessagesEntry(
+	entry ConversationEntryResetAfterMessages,
+) {
+	// Discard the specified number of inbound messages
+	for i := 0; i < entry.Count; i++ {
+		if _, ok := c.nextAnySegment(); !ok {
+			return
+		}
+	}
+	c.Close()
+}
+
 type MockAddr struct {
 	addr string
 }
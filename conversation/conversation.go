@@ -0,0 +1,71 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversation
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+)
+
+// Concat returns a conversation containing the entries from each of the provided fragments in order
+func Concat(
+	fragments ...[]ouroboros_mock.ConversationEntry,
+) []ouroboros_mock.ConversationEntry {
+	ret := []ouroboros_mock.ConversationEntry{}
+	for _, fragment := range fragments {
+		ret = append(ret, fragment...)
+	}
+	return ret
+}
+
+// WithHandshake returns a conversation that negotiates a handshake with the client before the entries from the
+// provided fragments. The highest version proposed by the client that gouroboros supports is accepted
+func WithHandshake(
+	fragments ...[]ouroboros_mock.ConversationEntry,
+) []ouroboros_mock.ConversationEntry {
+	return Concat(
+		append(
+			[][]ouroboros_mock.ConversationEntry{
+				{
+					ouroboros_mock.ConversationEntryHandshakeNegotiate{},
+				},
+			},
+			fragments...,
+		)...,
+	)
+}
+
+// Parallel returns a conversation that runs each of the provided fragments concurrently. Each fragment should
+// handle different mini-protocols
+func Parallel(
+	fragments ...[]ouroboros_mock.ConversationEntry,
+) []ouroboros_mock.ConversationEntry {
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryParallel{
+			Conversations: fragments,
+		},
+	}
+}
+
+// Repeat returns a conversation containing the entries from the provided fragment the specified number of times
+func Repeat(
+	count int,
+	fragment []ouroboros_mock.ConversationEntry,
+) []ouroboros_mock.ConversationEntry {
+	ret := []ouroboros_mock.ConversationEntry{}
+	for i := 0; i < count; i++ {
+		ret = append(ret, fragment...)
+	}
+	return ret
+}
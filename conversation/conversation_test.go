@@ -0,0 +1,152 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversation_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/conversation"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"go.uber.org/goleak"
+)
+
+var keepAliveFragment = []ouroboros_mock.ConversationEntry{
+	ouroboros_mock.ConversationEntryKeepAliveRequest,
+	ouroboros_mock.ConversationEntryKeepAliveResponse,
+}
+
+var findIntersectFragment = []ouroboros_mock.ConversationEntry{
+	ouroboros_mock.ConversationEntryInput{
+		ProtocolId:  chainsync.ProtocolIdNtN,
+		MessageType: chainsync.MessageTypeFindIntersect,
+	},
+	ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: chainsync.ProtocolIdNtN,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			chainsync.NewMsgIntersectNotFound(
+				chainsync.Tip{Point: common.NewPointOrigin()},
+			),
+		},
+	},
+}
+
+func TestConcat(t *testing.T) {
+	conv := conversation.Concat(keepAliveFragment, findIntersectFragment)
+	if len(conv) != 4 {
+		t.Fatalf("did not get expected number of entries: got %d, expected %d", len(conv), 4)
+	}
+	if _, ok := conv[2].(ouroboros_mock.ConversationEntryInput); !ok {
+		t.Fatalf("did not get expected entry type: got %T", conv[2])
+	}
+}
+
+func TestWithHandshake(t *testing.T) {
+	conv := conversation.WithHandshake(keepAliveFragment)
+	if len(conv) != 3 {
+		t.Fatalf("did not get expected number of entries: got %d, expected %d", len(conv), 3)
+	}
+	if _, ok := conv[0].(ouroboros_mock.ConversationEntryHandshakeNegotiate); !ok {
+		t.Fatalf("did not get expected entry type: got %T", conv[0])
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	conv := conversation.Repeat(3, keepAliveFragment)
+	if len(conv) != 6 {
+		t.Fatalf("did not get expected number of entries: got %d, expected %d", len(conv), 6)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation.Parallel(keepAliveFragment, findIntersectFragment),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	// Send the messages in the opposite order from the fragments
+	writeSegment(
+		t,
+		mockConn,
+		chainsync.ProtocolIdNtN,
+		chainsync.NewMsgFindIntersect([]common.Point{common.NewPointOrigin()}),
+	)
+	writeSegment(
+		t,
+		mockConn,
+		keepalive.ProtocolId,
+		keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie),
+	)
+	protocolIds := map[uint16]bool{}
+	for i := 0; i < 2; i++ {
+		protocolIds[readSegmentProtocolId(t, mockConn)] = true
+	}
+	if !protocolIds[chainsync.ProtocolIdNtN] || !protocolIds[keepalive.ProtocolId] {
+		t.Fatalf("did not get responses for all protocols: %v", protocolIds)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+// writeSegment writes a segment containing the provided message to the connection
+func writeSegment(t *testing.T, conn net.Conn, protocolId uint16, msg protocol.Message) {
+	t.Helper()
+	payload, err := cbor.Encode(msg)
+	if err != nil {
+		t.Fatalf("unexpected error encoding message: %s", err)
+	}
+	segment := muxer.NewSegment(protocolId, payload, false)
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.BigEndian, segment.SegmentHeader); err != nil {
+		t.Fatalf("unexpected error encoding segment header: %s", err)
+	}
+	buf.Write(segment.Payload)
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error writing segment: %s", err)
+	}
+}
+
+// readSegmentProtocolId reads a segment from the connection and returns its protocol ID
+func readSegmentProtocolId(t *testing.T, conn net.Conn) uint16 {
+	t.Helper()
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("unexpected error reading segment header: %s", err)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		t.Fatalf("unexpected error reading segment payload: %s", err)
+	}
+	return binary.BigEndian.Uint16(header[4:6]) & 0x7fff
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/blinklabs-io/gouroboros/protocol"
 	"github.com/blinklabs-io/gouroboros/protocol/handshake"
 	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
 )
 
 const (
@@ -29,6 +30,9 @@ const (
 	MockKeepAliveCookie    uint16 = 999
 )
 
+// MockResourceExhaustedReason is the reason provided when refusing a handshake due to resource exhaustion
+const MockResourceExhaustedReason = "resource exhausted: connection limit reached"
+
 type ConversationEntry interface {
 	isConversationEntry()
 }
@@ -44,15 +48,145 @@ type ConversationEntryInput struct {
 	Message         protocol.Message
 	MessageType     uint
 	MsgFromCborFunc protocol.MessageFromCborFunc
+	// Matcher is used instead of Message when set to accept any message that it matches. MsgFromCborFunc is used
+	// to decode the message
+	Matcher InputMatcherFunc
+	// MaxQueued is the maximum number of messages for the mini-protocol, including the matched message, that may
+	// have been received from the client without being processed yet. The conversation fails if more are queued,
+	// which limits how many requests a client can pipeline. A value of 0 disables the check
+	MaxQueued int
 }
 
+// InputMatcherFunc reports whether a message received from the client matches an input entry
+type InputMatcherFunc func(msg protocol.Message) bool
+
 type ConversationEntryOutput struct {
 	conversationEntryBase
 	ProtocolId uint16
 	IsResponse bool
 	Messages   []protocol.Message
+	// MessagesFunc is used instead of Messages when set. It's called when the entry is processed, which defers
+	// building and encoding the messages until they are sent and allows messages that depend on the time they are
+	// sent
+	MessagesFunc MessagesFunc
+	// SendAfter is a delay before sending the messages, measured from when the entry is reached
+	SendAfter time.Duration
+	// MinGap is the minimum time between the previous segment sent on the connection and sending the messages
+	MinGap time.Duration
+}
+
+// MessagesFunc returns the messages to send for an output entry
+type MessagesFunc func() ([]protocol.Message, error)
+
+// PayloadMutatorFunc returns a modified copy of a serialized message payload
+type PayloadMutatorFunc func(payload []byte) []byte
+
+// ConversationEntryFaultyOutput sends the messages from an output entry after passing the serialized segment
+// payload through a mutator, which allows deliberately sending malformed messages
+type ConversationEntryFaultyOutput struct {
+	conversationEntryBase
+	Output  ConversationEntryOutput
+	Mutator PayloadMutatorFunc
+}
+
+// WithCorruptedCbor returns a conversation entry that sends the messages from the provided output entry after
+// modifying the serialized payload with the provided mutator
+func WithCorruptedCbor(
+	entry ConversationEntryOutput,
+	mutator PayloadMutatorFunc,
+) ConversationEntryFaultyOutput {
+	return ConversationEntryFaultyOutput{
+		Output:  entry,
+		Mutator: mutator,
+	}
+}
+
+// WithTruncatedSegment returns a conversation entry that sends only the first n bytes of the serialized payload
+// for the provided output entry
+func WithTruncatedSegment(
+	entry ConversationEntryOutput,
+	n int,
+) ConversationEntryFaultyOutput {
+	return WithCorruptedCbor(
+		entry,
+		func(payload []byte) []byte {
+			if n < len(payload) {
+				return payload[:n]
+			}
+			return payload
+		},
+	)
+}
+
+// NewConversationEntryProtocolViolation returns a conversation entry that sends the provided messages on a
+// mini-protocol without regard for its state. Placing the entry where the client has agency, such as before the
+// client's first request, exercises the client's handling of state machine violations and the resulting muxer
+// shutdown
+func NewConversationEntryProtocolViolation(
+	protocolId uint16,
+	msgs ...protocol.Message,
+) ConversationEntryOutput {
+	return ConversationEntryOutput{
+		ProtocolId: protocolId,
+		IsResponse: true,
+		Messages:   msgs,
+	}
+}
+
+// ConversationEntryBranch matches a message from the client and continues with the Then entries if Predicate
+// accepts it or the Else entries otherwise, before returning to the rest of the conversation. MsgFromCborFunc is
+// used to decode the message
+type ConversationEntryBranch struct {
+	conversationEntryBase
+	ProtocolId      uint16
+	IsResponse      bool
+	MsgFromCborFunc protocol.MessageFromCborFunc
+	Predicate       InputMatcherFunc
+	Then            []ConversationEntry
+	Else            []ConversationEntry
+}
+
+// ConversationEntryResponder matches a message from the client and continues with the entries returned by
+// ResponseFunc for that message, before returning to the rest of the conversation. MsgFromCborFunc is used to
+// decode the message
+type ConversationEntryResponder struct {
+	conversationEntryBase
+	ProtocolId      uint16
+	IsResponse      bool
+	MsgFromCborFunc protocol.MessageFromCborFunc
+	ResponseFunc    ResponseFunc
+}
+
+// ResponseFunc returns the conversation entries used to respond to a message received from the client
+type ResponseFunc func(msg protocol.Message) ([]ConversationEntry, error)
+
+// ConversationEntryParallel runs each of the provided conversations concurrently and waits for all of them to
+// finish. Messages from the client are routed to the conversations by protocol ID, so each conversation should
+// handle different mini-protocols
+type ConversationEntryParallel struct {
+	conversationEntryBase
+	Conversations [][]ConversationEntry
+}
+
+// ConversationEntryLoop runs the provided entries repeatedly until the client disconnects or the connection is
+// closed. The entries should include at least one entry that waits for a message from the client
+type ConversationEntryLoop struct {
+	conversationEntryBase
+	Entries []ConversationEntry
+}
+
+// ConversationEntryStream runs the entries returned by successive calls to NextFunc until it returns no entries.
+// This allows serving very long conversations without building all of the entries up front. NextFunc is usually
+// stateful, so conversations containing this entry should be created per connection with WithConversationFunc
+type ConversationEntryStream struct {
+	conversationEntryBase
+	NextFunc StreamFunc
 }
 
+// StreamFunc returns the next batch of conversation entries for a stream entry, or no entries when the stream is
+// finished
+type StreamFunc func() ([]ConversationEntry, error)
+
 type ConversationEntryClose struct {
 	conversationEntryBase
 }
@@ -62,6 +196,34 @@ type ConversationEntrySleep struct {
 	Duration time.Duration
 }
 
+// ConversationEntryStall accepts the next message from the client for the specified mini-protocol and then sends
+// nothing for Duration before continuing with the conversation, simulating a node that stops responding. The
+// stall lasts until the connection is closed if Duration is zero
+type ConversationEntryStall struct {
+	conversationEntryBase
+	ProtocolId uint16
+	IsResponse bool
+	Duration   time.Duration
+}
+
+// ConversationEntryResetAfterMessages consumes the specified number of inbound messages without responding and
+// then closes the connection, simulating a node that drops connections under load
+type ConversationEntryResetAfterMessages struct {
+	conversationEntryBase
+	Count int
+}
+
+// ConversationEntryHandshakeNegotiate matches a handshake ProposeVersions message from a client and accepts the
+// highest proposed version that is also in Versions, or refuses the handshake with a version mismatch if there is
+// no such version. All versions supported by gouroboros are accepted with the mock network magic if Versions is nil.
+// If the client sets the query flag in the version data for the chosen version, a QueryReply containing
+// QueryVersions is sent instead, which defaults to Versions if not set
+type ConversationEntryHandshakeNegotiate struct {
+	conversationEntryBase
+	Versions      protocol.ProtocolVersionMap
+	QueryVersions protocol.ProtocolVersionMap
+}
+
 // ConversationEntryHandshakeRequestGeneric is a pre-defined conversation event that matches a generic
 // handshake request from a client
 var ConversationEntryHandshakeRequestGeneric = ConversationEntryInput{
@@ -100,6 +262,41 @@ var ConversationEntryHandshakeNtNResponse = ConversationEntryOutput{
 	},
 }
 
+// NewConversationEntryHandshakeRefuse returns a conversation entry for a server handshake response that refuses
+// the connection with the provided reason
+func NewConversationEntryHandshakeRefuse(
+	protocolVersion uint16,
+	reason string,
+) ConversationEntryOutput {
+	return ConversationEntryOutput{
+		ProtocolId: handshake.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			handshake.NewMsgRefuse(
+				[]any{
+					handshake.RefuseReasonRefused,
+					protocolVersion,
+					reason,
+				},
+			),
+		},
+	}
+}
+
+// ConversationEntryHandshakeNtNRefuseResourceExhausted is a pre-defined conversation entry for a server NtN
+// handshake response that refuses the connection due to resource exhaustion
+var ConversationEntryHandshakeNtNRefuseResourceExhausted = NewConversationEntryHandshakeRefuse(
+	MockProtocolVersionNtN,
+	MockResourceExhaustedReason,
+)
+
+// ConversationEntryHandshakeNtCRefuseResourceExhausted is a pre-defined conversation entry for a server NtC
+// handshake response that refuses the connection due to resource exhaustion
+var ConversationEntryHandshakeNtCRefuseResourceExhausted = NewConversationEntryHandshakeRefuse(
+	MockProtocolVersionNtC,
+	MockResourceExhaustedReason,
+)
+
 // ConversationEntryKeepAliveRequest is a pre-defined conversation entry for a keep-alive request
 var ConversationEntryKeepAliveRequest = ConversationEntryInput{
 	ProtocolId:      keepalive.ProtocolId,
@@ -116,6 +313,23 @@ var ConversationEntryKeepAliveResponse = ConversationEntryOutput{
 	},
 }
 
+// ConversationEntryLocalStateQueryAcquireVolatileTipRequest is a pre-defined conversation entry that matches a
+// local-state-query request to acquire the volatile tip
+var ConversationEntryLocalStateQueryAcquireVolatileTipRequest = ConversationEntryInput{
+	ProtocolId:  localstatequery.ProtocolId,
+	MessageType: localstatequery.MessageTypeAcquireVolatileTip,
+}
+
+// ConversationEntryLocalStateQueryAcquiredResponse is a pre-defined conversation entry for a local-state-query
+// acquired response
+var ConversationEntryLocalStateQueryAcquiredResponse = ConversationEntryOutput{
+	ProtocolId: localstatequery.ProtocolId,
+	IsResponse: true,
+	Messages: []protocol.Message{
+		localstatequery.NewMsgAcquired(),
+	},
+}
+
 // ConversationKeepAlive is a pre-defined conversation with a NtN handshake and repeated keep-alive requests
 // and responses
 var ConversationKeepAlive = []ConversationEntry{
@@ -139,3 +353,22 @@ var ConversationKeepAliveClose = []ConversationEntry{
 	ConversationEntryKeepAliveRequest,
 	ConversationEntryClose{},
 }
+
+// ConversationHandshakeNtNRefuseResourceExhausted is a pre-defined conversation that refuses a NtN handshake due
+// to resource exhaustion
+var ConversationHandshakeNtNRefuseResourceExhausted = []ConversationEntry{
+	ConversationEntryHandshakeRequestGeneric,
+	ConversationEntryHandshakeNtNRefuseResourceExhausted,
+}
+
+// NewConversationDelayedAcquire returns a conversation with a NtC handshake that waits for the specified duration
+// before responding to a local-state-query acquire request, simulating a node under load
+func NewConversationDelayedAcquire(delay time.Duration) []ConversationEntry {
+	return []ConversationEntry{
+		ConversationEntryHandshakeRequestGeneric,
+		ConversationEntryHandshakeNtCResponse,
+		ConversationEntryLocalStateQueryAcquireVolatileTipRequest,
+		ConversationEntrySleep{Duration: delay},
+		ConversationEntryLocalStateQueryAcquiredResponse,
+	}
+}
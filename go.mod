@@ -6,12 +6,13 @@ toolchain go1.22.9
 
 require (
 	github.com/blinklabs-io/gouroboros v0.106.1
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/gorilla/websocket v1.5.3
 	go.uber.org/goleak v1.3.0
 )
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/fxamacker/cbor/v2 v2.7.0 // indirect
 	github.com/jinzhu/copier v0.4.0 // indirect
 	github.com/utxorpc/go-codegen v0.15.0 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
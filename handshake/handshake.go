@@ -0,0 +1,173 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handshake
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	gouroboros_handshake "github.com/blinklabs-io/gouroboros/protocol/handshake"
+)
+
+// MockDeclineReason is the reason provided when declining a handshake
+const MockDeclineReason = "connection declined"
+
+// ConversationEntryProposeVersions is a pre-defined conversation entry that matches a ProposeVersions message
+// from a client. It's the same entry as ouroboros_mock.ConversationEntryHandshakeRequestGeneric
+var ConversationEntryProposeVersions = ouroboros_mock.ConversationEntryHandshakeRequestGeneric
+
+// ConversationVersionMismatch is a pre-defined conversation that refuses a handshake because none of the proposed
+// versions are supported
+var ConversationVersionMismatch = []ouroboros_mock.ConversationEntry{
+	ConversationEntryProposeVersions,
+	NewConversationEntryRefuseVersionMismatch(nil),
+}
+
+// ConversationDeclineNtC is a pre-defined conversation that declines a NtC handshake
+var ConversationDeclineNtC = []ouroboros_mock.ConversationEntry{
+	ConversationEntryProposeVersions,
+	NewConversationEntryRefuse(
+		ouroboros_mock.MockProtocolVersionNtC,
+		MockDeclineReason,
+	),
+}
+
+// ConversationDeclineNtN is a pre-defined conversation that declines a NtN handshake
+var ConversationDeclineNtN = []ouroboros_mock.ConversationEntry{
+	ConversationEntryProposeVersions,
+	NewConversationEntryRefuse(
+		ouroboros_mock.MockProtocolVersionNtN,
+		MockDeclineReason,
+	),
+}
+
+// NewConversationEntryAcceptVersion returns a conversation entry for an AcceptVersion message with the provided
+// version and version data
+func NewConversationEntryAcceptVersion(
+	version uint16,
+	versionData protocol.VersionData,
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: gouroboros_handshake.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			gouroboros_handshake.NewMsgAcceptVersion(version, versionData),
+		},
+	}
+}
+
+// NewConversationEntryAcceptVersionNtC returns a conversation entry for an AcceptVersion message with the
+// provided NtC version and the matching version data for the mock network
+func NewConversationEntryAcceptVersionNtC(
+	version uint16,
+) ouroboros_mock.ConversationEntryOutput {
+	return NewConversationEntryAcceptVersion(
+		version,
+		VersionDataNtC(version, ouroboros_mock.MockNetworkMagic),
+	)
+}
+
+// NewConversationEntryAcceptVersionNtN returns a conversation entry for an AcceptVersion message with the
+// provided NtN version and the matching version data for the mock network
+func NewConversationEntryAcceptVersionNtN(
+	version uint16,
+) ouroboros_mock.ConversationEntryOutput {
+	return NewConversationEntryAcceptVersion(
+		version,
+		VersionDataNtN(version, ouroboros_mock.MockNetworkMagic),
+	)
+}
+
+// NewConversationEntryQueryReply returns a conversation entry for a QueryReply message with the provided version
+// table, which is the response to a client that sets the query flag in its proposed version data
+func NewConversationEntryQueryReply(
+	versions protocol.ProtocolVersionMap,
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: gouroboros_handshake.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			ouroboros_mock.NewMsgHandshakeQueryReply(versions),
+		},
+	}
+}
+
+// NewConversationEntryRefuseVersionMismatch returns a conversation entry for a Refuse message indicating that
+// none of the proposed versions are supported, along with the versions that are
+func NewConversationEntryRefuseVersionMismatch(
+	supportedVersions []uint16,
+) ouroboros_mock.ConversationEntryOutput {
+	if supportedVersions == nil {
+		supportedVersions = []uint16{}
+	}
+	return newConversationEntryRefuse(
+		[]any{
+			gouroboros_handshake.RefuseReasonVersionMismatch,
+			supportedVersions,
+		},
+	)
+}
+
+// NewConversationEntryRefuseDecodeError returns a conversation entry for a Refuse message indicating that the
+// version data for the provided version couldn't be decoded
+func NewConversationEntryRefuseDecodeError(
+	version uint16,
+	reason string,
+) ouroboros_mock.ConversationEntryOutput {
+	return newConversationEntryRefuse(
+		[]any{
+			gouroboros_handshake.RefuseReasonDecodeError,
+			version,
+			reason,
+		},
+	)
+}
+
+// NewConversationEntryRefuse returns a conversation entry for a Refuse message that declines the connection
+// with the provided reason. It wraps ouroboros_mock.NewConversationEntryHandshakeRefuse
+func NewConversationEntryRefuse(
+	version uint16,
+	reason string,
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.NewConversationEntryHandshakeRefuse(version, reason)
+}
+
+func newConversationEntryRefuse(
+	reason []any,
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: gouroboros_handshake.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			gouroboros_handshake.NewMsgRefuse(reason),
+		},
+	}
+}
+
+// NewConversationHandshakeNtC returns a conversation that accepts a NtC handshake with the provided version
+func NewConversationHandshakeNtC(version uint16) []ouroboros_mock.ConversationEntry {
+	return []ouroboros_mock.ConversationEntry{
+		ConversationEntryProposeVersions,
+		NewConversationEntryAcceptVersionNtC(version),
+	}
+}
+
+// NewConversationHandshakeNtN returns a conversation that accepts a NtN handshake with the provided version
+func NewConversationHandshakeNtN(version uint16) []ouroboros_mock.ConversationEntry {
+	return []ouroboros_mock.ConversationEntry{
+		ConversationEntryProposeVersions,
+		NewConversationEntryAcceptVersionNtN(version),
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handshake_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/handshake"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"go.uber.org/goleak"
+)
+
+func TestAcceptVersion(t *testing.T) {
+	testDefs := []struct {
+		name         string
+		conversation []ouroboros_mock.ConversationEntry
+		nodeToNode   bool
+	}{
+		{
+			name:         "NtC",
+			conversation: handshake.NewConversationHandshakeNtC(ouroboros_mock.MockProtocolVersionNtC),
+		},
+		{
+			name:         "NtN",
+			conversation: handshake.NewConversationHandshakeNtN(ouroboros_mock.MockProtocolVersionNtN),
+			nodeToNode:   true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				testDef.conversation,
+			)
+			// Async mock connection error handler
+			go func() {
+				err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+				if ok {
+					panic(err)
+				}
+			}()
+			oConn, err := ouroboros.New(
+				ouroboros.WithConnection(mockConn),
+				ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+				ouroboros.WithNodeToNode(testDef.nodeToNode),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+			}
+			// Close Ouroboros connection
+			if err := oConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+			}
+			// Wait for connection shutdown
+			select {
+			case <-oConn.ErrorChan():
+			case <-time.After(10 * time.Second):
+				t.Errorf("did not shutdown within timeout")
+			}
+		})
+	}
+}
+
+func TestRefuse(t *testing.T) {
+	testDefs := []struct {
+		name          string
+		conversation  []ouroboros_mock.ConversationEntry
+		nodeToNode    bool
+		expectedError string
+	}{
+		{
+			name:          "VersionMismatchNtC",
+			conversation:  handshake.ConversationVersionMismatch,
+			expectedError: "version mismatch",
+		},
+		{
+			name:          "VersionMismatchNtN",
+			conversation:  handshake.ConversationVersionMismatch,
+			nodeToNode:    true,
+			expectedError: "version mismatch",
+		},
+		{
+			name:          "DeclineNtC",
+			conversation:  handshake.ConversationDeclineNtC,
+			expectedError: handshake.MockDeclineReason,
+		},
+		{
+			name:          "DeclineNtN",
+			conversation:  handshake.ConversationDeclineNtN,
+			nodeToNode:    true,
+			expectedError: handshake.MockDeclineReason,
+		},
+		{
+			name: "DecodeError",
+			conversation: []ouroboros_mock.ConversationEntry{
+				handshake.ConversationEntryProposeVersions,
+				handshake.NewConversationEntryRefuseDecodeError(
+					ouroboros_mock.MockProtocolVersionNtN,
+					"invalid version data",
+				),
+			},
+			nodeToNode:    true,
+			expectedError: "invalid version data",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				testDef.conversation,
+			)
+			_, err := ouroboros.New(
+				ouroboros.WithConnection(mockConn),
+				ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+				ouroboros.WithNodeToNode(testDef.nodeToNode),
+			)
+			if err == nil {
+				t.Fatalf("did not receive expected error")
+			}
+			if !strings.Contains(err.Error(), testDef.expectedError) {
+				t.Fatalf("did not receive expected error: got %s", err)
+			}
+			if err := mockConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing mock connection: %s", err)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	testDefs := []struct {
+		name            string
+		versions        protocol.ProtocolVersionMap
+		nodeToNode      bool
+		expectedVersion uint16
+		expectedError   string
+	}{
+		{
+			name:            "DefaultNtC",
+			expectedVersion: handshake.VersionsNtC()[len(handshake.VersionsNtC())-1],
+		},
+		{
+			name:            "DefaultNtN",
+			nodeToNode:      true,
+			expectedVersion: handshake.VersionsNtN()[len(handshake.VersionsNtN())-1],
+		},
+		{
+			name:            "TableNtN",
+			versions:        handshake.NewVersionTableNtN(11, 12, 99),
+			nodeToNode:      true,
+			expectedVersion: 12,
+		},
+		{
+			name:            "TableNtC",
+			versions:        handshake.NewVersionTableNtC(ouroboros_mock.MockProtocolVersionNtC),
+			expectedVersion: ouroboros_mock.MockProtocolVersionNtC,
+		},
+		{
+			name:          "VersionMismatch",
+			versions:      handshake.NewVersionTableNtN(99),
+			nodeToNode:    true,
+			expectedError: "version mismatch",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				[]ouroboros_mock.ConversationEntry{
+					ouroboros_mock.ConversationEntryHandshakeNegotiate{
+						Versions: testDef.versions,
+					},
+				},
+			)
+			oConn, err := ouroboros.New(
+				ouroboros.WithConnection(mockConn),
+				ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+				ouroboros.WithNodeToNode(testDef.nodeToNode),
+			)
+			if testDef.expectedError != "" {
+				if err == nil {
+					t.Fatalf("did not receive expected error")
+				}
+				if !strings.Contains(err.Error(), testDef.expectedError) {
+					t.Fatalf("did not receive expected error: got %s", err)
+				}
+				if err := mockConn.Close(); err != nil {
+					t.Fatalf("unexpected error when closing mock connection: %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+			}
+			version, _ := oConn.ProtocolVersion()
+			if version != testDef.expectedVersion {
+				t.Fatalf("did not negotiate expected version: got %d, expected %d", version, testDef.expectedVersion)
+			}
+			// Close Ouroboros connection
+			if err := oConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+			}
+			// Wait for connection shutdown
+			select {
+			case <-oConn.ErrorChan():
+			case <-time.After(10 * time.Second):
+				t.Errorf("did not shutdown within timeout")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handshake
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+)
+
+// VersionsNtC returns the NtC protocol versions supported by gouroboros, in ascending order
+func VersionsNtC() []uint16 {
+	return protocol.GetProtocolVersionsNtC()
+}
+
+// VersionsNtN returns the NtN protocol versions supported by gouroboros, in ascending order
+func VersionsNtN() []uint16 {
+	return protocol.GetProtocolVersionsNtN()
+}
+
+// VersionDataNtC returns the version data for the provided NtC version and network magic
+func VersionDataNtC(version uint16, networkMagic uint32) protocol.VersionData {
+	if version >= (15 + protocol.ProtocolVersionNtCOffset) {
+		return protocol.VersionDataNtC15andUp{
+			CborNetworkMagic: networkMagic,
+		}
+	}
+	return protocol.VersionDataNtC9to14(networkMagic)
+}
+
+// VersionDataNtN returns the version data for the provided NtN version and network magic, using initiator-only
+// diffusion mode with peer sharing and query mode disabled
+func VersionDataNtN(version uint16, networkMagic uint32) protocol.VersionData {
+	switch {
+	case version >= 13:
+		return protocol.VersionDataNtN13andUp{
+			VersionDataNtN11to12: protocol.VersionDataNtN11to12{
+				CborNetworkMagic:                       networkMagic,
+				CborInitiatorAndResponderDiffusionMode: protocol.DiffusionModeInitiatorOnly,
+				CborPeerSharing:                        protocol.PeerSharingModeNoPeerSharing,
+				CborQuery:                              protocol.QueryModeDisabled,
+			},
+		}
+	case version >= 11:
+		return protocol.VersionDataNtN11to12{
+			CborNetworkMagic:                       networkMagic,
+			CborInitiatorAndResponderDiffusionMode: protocol.DiffusionModeInitiatorOnly,
+			CborPeerSharing:                        protocol.PeerSharingModeV11NoPeerSharing,
+			CborQuery:                              protocol.QueryModeDisabled,
+		}
+	default:
+		return protocol.VersionDataNtN7to10{
+			CborNetworkMagic:                       networkMagic,
+			CborInitiatorAndResponderDiffusionMode: protocol.DiffusionModeInitiatorOnly,
+		}
+	}
+}
+
+// NewVersionTableNtC returns a version table for use with ouroboros_mock.ConversationEntryHandshakeNegotiate
+// containing the provided NtC versions with the mock network magic
+func NewVersionTableNtC(versions ...uint16) protocol.ProtocolVersionMap {
+	ret := protocol.ProtocolVersionMap{}
+	for _, version := range versions {
+		ret[version] = VersionDataNtC(version, ouroboros_mock.MockNetworkMagic)
+	}
+	return ret
+}
+
+// NewVersionTableNtN returns a version table for use with ouroboros_mock.ConversationEntryHandshakeNegotiate
+// containing the provided NtN versions with the mock network magic
+func NewVersionTableNtN(versions ...uint16) protocol.ProtocolVersionMap {
+	ret := protocol.ProtocolVersionMap{}
+	for _, version := range versions {
+		ret[version] = VersionDataNtN(version, ouroboros_mock.MockNetworkMagic)
+	}
+	return ret
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+)
+
+// MessageTypeHandshakeQueryReply is the handshake message type for a QueryReply, which isn't provided by gouroboros
+const MessageTypeHandshakeQueryReply = 3
+
+// MsgHandshakeQueryReply is a handshake QueryReply message, which a server sends with its version table in place of
+// AcceptVersion when the client sets the query flag in its proposed version data
+type MsgHandshakeQueryReply struct {
+	protocol.MessageBase
+	VersionMap map[uint16]cbor.RawMessage
+}
+
+// NewMsgHandshakeQueryReply returns a QueryReply message containing the provided version table
+func NewMsgHandshakeQueryReply(
+	versionMap protocol.ProtocolVersionMap,
+) *MsgHandshakeQueryReply {
+	rawVersionMap := map[uint16]cbor.RawMessage{}
+	for version, versionData := range versionMap {
+		// This should never fail with the known VersionData types
+		cborData, _ := cbor.Encode(&versionData)
+		rawVersionMap[version] = cbor.RawMessage(cborData)
+	}
+	return &MsgHandshakeQueryReply{
+		MessageBase: protocol.MessageBase{
+			MessageType: MessageTypeHandshakeQueryReply,
+		},
+		VersionMap: rawVersionMap,
+	}
+}
+
+// handshakeQueryRequested returns whether the query flag is set in the provided version data from a client
+func handshakeQueryRequested(version uint16, versionDataCbor []byte) bool {
+	versionDataFunc := protocol.GetProtocolVersion(version).NewVersionDataFromCborFunc
+	if versionDataFunc == nil {
+		return false
+	}
+	versionData, err := versionDataFunc(versionDataCbor)
+	if err != nil {
+		return false
+	}
+	switch v := versionData.(type) {
+	case protocol.VersionDataNtC15andUp:
+		return v.CborQuery
+	case protocol.VersionDataNtN11to12:
+		return v.CborQuery
+	case protocol.VersionDataNtN13andUp:
+		return v.CborQuery
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"crypto/ed25519"
+	"math/rand"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// AddressGenerator generates deterministic keys and addresses for test personas. The same network ID and seed
+// always produce the same sequence of personas
+type AddressGenerator struct {
+	networkId uint8
+	rng       *rand.Rand
+}
+
+// NewAddressGenerator returns a new AddressGenerator for the provided network ID and seed. Use
+// common.AddressNetworkTestnet or common.AddressNetworkMainnet for the network ID
+func NewAddressGenerator(networkId uint8, seed int64) *AddressGenerator {
+	return &AddressGenerator{
+		networkId: networkId,
+		// #nosec G404
+		rng: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Persona is a party in a test scenario, with payment and stake keys and the addresses built from them
+type Persona struct {
+	NetworkId      uint8
+	PaymentKey     ed25519.PrivateKey
+	StakeKey       ed25519.PrivateKey
+	PaymentKeyHash common.Blake2b224
+	StakeKeyHash   common.Blake2b224
+	// BaseAddress is the payment address delegated with the stake key
+	BaseAddress common.Address
+	// EnterpriseAddress is the payment address without a stake key
+	EnterpriseAddress common.Address
+	// StakeAddress is the reward address for the stake key
+	StakeAddress common.Address
+}
+
+// StakePointer is the location of a stake registration certificate on the chain, as used by pointer addresses
+type StakePointer struct {
+	Slot      uint64
+	TxIndex   uint64
+	CertIndex uint64
+}
+
+// NewPersona returns a new persona with the next keys from the generator
+func (g *AddressGenerator) NewPersona() (Persona, error) {
+	ret := Persona{
+		NetworkId:  g.networkId,
+		PaymentKey: g.newKey(),
+		StakeKey:   g.newKey(),
+	}
+	ret.PaymentKeyHash = VkeyHash(ret.PaymentKey.Public().(ed25519.PublicKey))
+	ret.StakeKeyHash = VkeyHash(ret.StakeKey.Public().(ed25519.PublicKey))
+	var err error
+	ret.BaseAddress, err = newAddressFromBytes(
+		addressHeader(common.AddressTypeKeyKey, g.networkId),
+		ret.PaymentKeyHash.Bytes(),
+		ret.StakeKeyHash.Bytes(),
+	)
+	if err != nil {
+		return Persona{}, err
+	}
+	ret.EnterpriseAddress, err = newAddressFromBytes(
+		addressHeader(common.AddressTypeKeyNone, g.networkId),
+		ret.PaymentKeyHash.Bytes(),
+	)
+	if err != nil {
+		return Persona{}, err
+	}
+	ret.StakeAddress, err = newAddressFromBytes(
+		addressHeader(common.AddressTypeNoneKey, g.networkId),
+		ret.StakeKeyHash.Bytes(),
+	)
+	if err != nil {
+		return Persona{}, err
+	}
+	return ret, nil
+}
+
+// PointerAddress returns the payment address for the persona with its stake referenced by the provided pointer
+func (p Persona) PointerAddress(pointer StakePointer) (common.Address, error) {
+	return newAddressFromBytes(
+		addressHeader(common.AddressTypeKeyPointer, p.NetworkId),
+		p.PaymentKeyHash.Bytes(),
+		encodeVariableNat(pointer.Slot),
+		encodeVariableNat(pointer.TxIndex),
+		encodeVariableNat(pointer.CertIndex),
+	)
+}
+
+// newKey returns the next ed25519 key from the generator
+func (g *AddressGenerator) newKey() ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	// This never returns an error
+	_, _ = g.rng.Read(seed)
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// addressHeader returns the header byte for an address of the provided type and network
+func addressHeader(addrType uint8, networkId uint8) []byte {
+	return []byte{(addrType << 4) | (networkId & common.AddressHeaderNetworkMask)}
+}
+
+// newAddressFromBytes returns the address with the provided raw parts, which are concatenated
+func newAddressFromBytes(parts ...[]byte) (common.Address, error) {
+	var data []byte
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	// Addresses can only be built from raw bytes by decoding them from CBOR
+	dataCbor, err := cbor.Encode(data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var ret common.Address
+	if _, err := cbor.Decode(dataCbor, &ret); err != nil {
+		return common.Address{}, err
+	}
+	return ret, nil
+}
+
+// encodeVariableNat encodes a natural number as used in pointer addresses, in big-endian groups of 7 bits with
+// the high bit set on all but the last byte
+func encodeVariableNat(val uint64) []byte {
+	ret := []byte{byte(val & 0x7f)}
+	val >>= 7
+	for val > 0 {
+		ret = append([]byte{byte(val&0x7f) | 0x80}, ret...)
+		val >>= 7
+	}
+	return ret
+}
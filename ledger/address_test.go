@@ -0,0 +1,115 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestAddressGenerator(t *testing.T) {
+	gen := ledger.NewAddressGenerator(common.AddressNetworkTestnet, 1)
+	alice, err := gen.NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	bob, err := gen.NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	if alice.PaymentKeyHash == bob.PaymentKeyHash || alice.StakeKeyHash == bob.StakeKeyHash {
+		t.Fatalf("personas should not share keys")
+	}
+	// The same seed produces the same personas
+	aliceAgain, err := ledger.NewAddressGenerator(common.AddressNetworkTestnet, 1).NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	if aliceAgain.BaseAddress.String() != alice.BaseAddress.String() {
+		t.Fatalf("did not get same address for same seed: got %s, expected %s", aliceAgain.BaseAddress, alice.BaseAddress)
+	}
+	testDefs := []struct {
+		name           string
+		address        common.Address
+		prefix         string
+		paymentKeyHash *common.Blake2b224
+		stakeKeyHash   *common.Blake2b224
+	}{
+		{
+			name:           "base",
+			address:        alice.BaseAddress,
+			prefix:         "addr_test1q",
+			paymentKeyHash: &alice.PaymentKeyHash,
+			stakeKeyHash:   &alice.StakeKeyHash,
+		},
+		{
+			name:           "enterprise",
+			address:        alice.EnterpriseAddress,
+			prefix:         "addr_test1v",
+			paymentKeyHash: &alice.PaymentKeyHash,
+		},
+		{
+			name:         "stake",
+			address:      alice.StakeAddress,
+			prefix:       "stake_test1u",
+			stakeKeyHash: &alice.StakeKeyHash,
+		},
+	}
+	for _, testDef := range testDefs {
+		if !strings.HasPrefix(testDef.address.String(), testDef.prefix) {
+			t.Fatalf("%s address did not have expected prefix: got %s, expected %s", testDef.name, testDef.address, testDef.prefix)
+		}
+		if testDef.paymentKeyHash != nil && testDef.address.PaymentKeyHash() != *testDef.paymentKeyHash {
+			t.Fatalf("%s address did not have expected payment key hash", testDef.name)
+		}
+		if testDef.stakeKeyHash != nil && testDef.address.StakeKeyHash() != *testDef.stakeKeyHash {
+			t.Fatalf("%s address did not have expected stake key hash", testDef.name)
+		}
+	}
+}
+
+func TestPersonaPointerAddress(t *testing.T) {
+	persona, err := ledger.NewAddressGenerator(common.AddressNetworkMainnet, 1).NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	// Pointer from the CIP-19 test vectors
+	addr, err := persona.PointerAddress(
+		ledger.StakePointer{Slot: 2498243, TxIndex: 27, CertIndex: 3},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building pointer address: %s", err)
+	}
+	expectedPointer, _ := hex.DecodeString("8198bd431b03")
+	addrBytes := addr.Bytes()
+	if addrBytes[0] != 0x41 {
+		t.Fatalf("did not get expected header byte: got %#x, expected 0x41", addrBytes[0])
+	}
+	if !bytes.Equal(addrBytes[1:29], persona.PaymentKeyHash.Bytes()) {
+		t.Fatalf("did not get expected payment key hash: %x", addrBytes[1:29])
+	}
+	if !bytes.Equal(addrBytes[29:], expectedPointer) {
+		t.Fatalf("did not get expected pointer: got %x, expected %x", addrBytes[29:], expectedPointer)
+	}
+	if !strings.HasPrefix(addr.String(), "addr1g") {
+		t.Fatalf("did not get expected address prefix: %s", addr)
+	}
+}
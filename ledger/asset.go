@@ -0,0 +1,134 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// AssetFingerprint returns the CIP-14 fingerprint for the provided asset
+func AssetFingerprint(policyId common.Blake2b224, assetName []byte) string {
+	return common.NewAssetFingerprint(policyId.Bytes(), assetName).String()
+}
+
+// NewPolicyId returns the policy ID for the provided hex string
+func NewPolicyId(policyIdHex string) (common.Blake2b224, error) {
+	policyId, err := hex.DecodeString(policyIdHex)
+	if err != nil {
+		return common.Blake2b224{}, fmt.Errorf("invalid policy ID: %w", err)
+	}
+	if len(policyId) != common.Blake2b224Size {
+		return common.Blake2b224{}, fmt.Errorf(
+			"invalid policy ID length: expected %d bytes, got %d",
+			common.Blake2b224Size,
+			len(policyId),
+		)
+	}
+	return common.NewBlake2b224(policyId), nil
+}
+
+// AssetSpec is an asset and quantity parsed from a human-readable spec
+type AssetSpec struct {
+	PolicyId  common.Blake2b224
+	AssetName []byte
+	Quantity  uint64
+}
+
+// ParseAssetSpec parses an asset spec in the form "<policy ID hex>.<asset name>=<quantity>". The asset name is
+// taken as text, or as hex when prefixed with "0x", and may be empty
+func ParseAssetSpec(spec string) (AssetSpec, error) {
+	assetPart, quantityPart, ok := strings.Cut(spec, "=")
+	if !ok {
+		return AssetSpec{}, fmt.Errorf("asset spec is missing quantity: %s", spec)
+	}
+	quantity, err := strconv.ParseUint(quantityPart, 10, 64)
+	if err != nil {
+		return AssetSpec{}, fmt.Errorf("invalid quantity in asset spec %s: %w", spec, err)
+	}
+	policyIdHex, assetNameStr, _ := strings.Cut(assetPart, ".")
+	policyId, err := NewPolicyId(policyIdHex)
+	if err != nil {
+		return AssetSpec{}, fmt.Errorf("invalid asset spec %s: %w", spec, err)
+	}
+	assetName := []byte(assetNameStr)
+	if hexName, ok := strings.CutPrefix(assetNameStr, "0x"); ok {
+		assetName, err = hex.DecodeString(hexName)
+		if err != nil {
+			return AssetSpec{}, fmt.Errorf("invalid asset name in asset spec %s: %w", spec, err)
+		}
+	}
+	return AssetSpec{
+		PolicyId:  policyId,
+		AssetName: assetName,
+		Quantity:  quantity,
+	}, nil
+}
+
+// MultiAssetBuilder builds a multi-asset value for use in transaction outputs. Quantities for the same asset are
+// added together
+type MultiAssetBuilder struct {
+	data map[common.Blake2b224]map[cbor.ByteString]common.MultiAssetTypeOutput
+}
+
+// NewMultiAssetBuilder returns a new MultiAssetBuilder
+func NewMultiAssetBuilder() *MultiAssetBuilder {
+	return &MultiAssetBuilder{
+		data: make(map[common.Blake2b224]map[cbor.ByteString]common.MultiAssetTypeOutput),
+	}
+}
+
+// Add adds the specified quantity of the provided asset
+func (b *MultiAssetBuilder) Add(
+	policyId common.Blake2b224,
+	assetName []byte,
+	quantity uint64,
+) *MultiAssetBuilder {
+	policy, ok := b.data[policyId]
+	if !ok {
+		policy = make(map[cbor.ByteString]common.MultiAssetTypeOutput)
+		b.data[policyId] = policy
+	}
+	policy[cbor.NewByteString(assetName)] += quantity
+	return b
+}
+
+// AddSpecs adds the assets from the provided asset specs. See ParseAssetSpec for the format
+func (b *MultiAssetBuilder) AddSpecs(specs ...string) error {
+	for _, spec := range specs {
+		asset, err := ParseAssetSpec(spec)
+		if err != nil {
+			return err
+		}
+		b.Add(asset.PolicyId, asset.AssetName, asset.Quantity)
+	}
+	return nil
+}
+
+// Build returns the multi-asset value
+func (b *MultiAssetBuilder) Build() common.MultiAsset[common.MultiAssetTypeOutput] {
+	// Copy the data so that later calls to Add don't modify the returned value
+	data := make(map[common.Blake2b224]map[cbor.ByteString]common.MultiAssetTypeOutput, len(b.data))
+	for policyId, policy := range b.data {
+		data[policyId] = maps.Clone(policy)
+	}
+	return common.NewMultiAsset(data)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+)
+
+const testPolicyId = "7eae28af2208be856f7a119668ae52a49b73725e326dc16579dcc373"
+
+func TestAssetFingerprint(t *testing.T) {
+	// Test vectors from CIP-14
+	testDefs := []struct {
+		policyId    string
+		assetName   string
+		fingerprint string
+	}{
+		{
+			policyId:    "7eae28af2208be856f7a119668ae52a49b73725e326dc16579dcc373",
+			fingerprint: "asset1rjklcrnsdzqp65wjgrg55sy9723kw09mlgvlc3",
+		},
+		{
+			policyId:    "1e349c9bdea19fd6c147626a5260bc44b71635f398b67c59881df209",
+			fingerprint: "asset1uyuxku60yqe57nusqzjx38aan3f2wq6s93f6ea",
+		},
+		{
+			policyId:    "7eae28af2208be856f7a119668ae52a49b73725e326dc16579dcc373",
+			assetName:   "504154415445",
+			fingerprint: "asset13n25uv0yaf5kus35fm2k86cqy60z58d9xmde92",
+		},
+	}
+	for _, testDef := range testDefs {
+		policyId, err := ledger.NewPolicyId(testDef.policyId)
+		if err != nil {
+			t.Fatalf("unexpected error decoding policy ID: %s", err)
+		}
+		assetName, err := hex.DecodeString(testDef.assetName)
+		if err != nil {
+			t.Fatalf("unexpected error decoding asset name: %s", err)
+		}
+		fingerprint := ledger.AssetFingerprint(policyId, assetName)
+		if fingerprint != testDef.fingerprint {
+			t.Fatalf("did not get expected fingerprint: got %s, expected %s", fingerprint, testDef.fingerprint)
+		}
+	}
+}
+
+func TestParseAssetSpec(t *testing.T) {
+	asset, err := ledger.ParseAssetSpec(testPolicyId + ".PATATE=42")
+	if err != nil {
+		t.Fatalf("unexpected error parsing asset spec: %s", err)
+	}
+	if asset.PolicyId.String() != testPolicyId || string(asset.AssetName) != "PATATE" || asset.Quantity != 42 {
+		t.Fatalf("did not get expected asset: %#v", asset)
+	}
+	// Hex asset names are decoded
+	asset, err = ledger.ParseAssetSpec(testPolicyId + ".0x504154415445=1")
+	if err != nil {
+		t.Fatalf("unexpected error parsing asset spec: %s", err)
+	}
+	if string(asset.AssetName) != "PATATE" {
+		t.Fatalf("did not get expected asset name: %x", asset.AssetName)
+	}
+	// The asset name may be omitted
+	asset, err = ledger.ParseAssetSpec(testPolicyId + "=1")
+	if err != nil {
+		t.Fatalf("unexpected error parsing asset spec: %s", err)
+	}
+	if len(asset.AssetName) != 0 {
+		t.Fatalf("did not get expected empty asset name: %x", asset.AssetName)
+	}
+	for _, spec := range []string{
+		testPolicyId + ".PATATE",
+		testPolicyId + ".PATATE=-1",
+		"abcd.PATATE=1",
+		testPolicyId + ".0xzz=1",
+	} {
+		if _, err := ledger.ParseAssetSpec(spec); err == nil {
+			t.Fatalf("did not receive expected error for spec: %s", spec)
+		}
+	}
+}
+
+func TestMultiAssetBuilder(t *testing.T) {
+	policyId, err := ledger.NewPolicyId(testPolicyId)
+	if err != nil {
+		t.Fatalf("unexpected error decoding policy ID: %s", err)
+	}
+	builder := ledger.NewMultiAssetBuilder().
+		Add(policyId, []byte("PATATE"), 10)
+	err = builder.AddSpecs(
+		testPolicyId+".PATATE=5",
+		testPolicyId+".OTHER=7",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error adding asset specs: %s", err)
+	}
+	multiAsset := builder.Build()
+	if qty := multiAsset.Asset(policyId, []byte("PATATE")); qty != 15 {
+		t.Fatalf("did not get expected quantity: got %d, expected 15", qty)
+	}
+	if qty := multiAsset.Asset(policyId, []byte("OTHER")); qty != 7 {
+		t.Fatalf("did not get expected quantity: got %d, expected 7", qty)
+	}
+	// Later additions don't modify the built value
+	builder.Add(policyId, []byte("PATATE"), 1)
+	if qty := multiAsset.Asset(policyId, []byte("PATATE")); qty != 15 {
+		t.Fatalf("built value was modified: got %d, expected 15", qty)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// CertificateBuilder builds certificates for embedding in transactions. The certificates are built from their
+// wire encoding, so Cbor() returns the encoded certificate
+type CertificateBuilder struct {
+	deposit uint64
+	anchor  *common.GovAnchor
+}
+
+// NewCertificateBuilder returns a new CertificateBuilder
+func NewCertificateBuilder() *CertificateBuilder {
+	return &CertificateBuilder{}
+}
+
+// WithDeposit specifies the deposit amount. This is used by the Registration, Deregistration,
+// StakeRegistrationDelegation, VoteRegistrationDelegation, StakeVoteRegistrationDelegation, RegistrationDrep, and
+// DeregistrationDrep certificates
+func (b *CertificateBuilder) WithDeposit(amount uint64) *CertificateBuilder {
+	b.deposit = amount
+	return b
+}
+
+// WithAnchor specifies the metadata anchor. This is used by the ResignCommitteeCold, RegistrationDrep, and
+// UpdateDrep certificates, which have no anchor by default
+func (b *CertificateBuilder) WithAnchor(anchor common.GovAnchor) *CertificateBuilder {
+	b.anchor = &anchor
+	return b
+}
+
+// StakeRegistration returns a pre-Conway StakeRegistration certificate, which uses the deposit from the protocol
+// parameters
+func (b *CertificateBuilder) StakeRegistration(
+	cred common.StakeCredential,
+) (*common.StakeRegistrationCertificate, error) {
+	var ret common.StakeRegistrationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeStakeRegistration,
+		cred,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// StakeDeregistration returns a pre-Conway StakeDeregistration certificate
+func (b *CertificateBuilder) StakeDeregistration(
+	cred common.StakeCredential,
+) (*common.StakeDeregistrationCertificate, error) {
+	var ret common.StakeDeregistrationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeStakeDeregistration,
+		cred,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// StakeDelegation returns a StakeDelegation certificate which delegates the stake credential to a pool
+func (b *CertificateBuilder) StakeDelegation(
+	cred common.StakeCredential,
+	pool common.PoolKeyHash,
+) (*common.StakeDelegationCertificate, error) {
+	var ret common.StakeDelegationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeStakeDelegation,
+		cred,
+		pool,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// PoolRetirement returns a PoolRetirement certificate which retires the pool at the specified epoch
+func (b *CertificateBuilder) PoolRetirement(
+	pool common.PoolKeyHash,
+	epoch uint64,
+) (*common.PoolRetirementCertificate, error) {
+	var ret common.PoolRetirementCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypePoolRetirement,
+		pool,
+		epoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// Registration returns a Conway Registration certificate for the stake credential with the deposit
+func (b *CertificateBuilder) Registration(
+	cred common.StakeCredential,
+) (*common.RegistrationCertificate, error) {
+	var ret common.RegistrationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeRegistration,
+		cred,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// Deregistration returns a Conway Deregistration certificate for the stake credential with the deposit refund
+func (b *CertificateBuilder) Deregistration(
+	cred common.StakeCredential,
+) (*common.DeregistrationCertificate, error) {
+	var ret common.DeregistrationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeDeregistration,
+		cred,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// VoteDelegation returns a VoteDelegation certificate which delegates the votes of the stake credential to a DRep
+func (b *CertificateBuilder) VoteDelegation(
+	cred common.StakeCredential,
+	drep common.Drep,
+) (*common.VoteDelegationCertificate, error) {
+	drepData, err := encodeDrep(drep)
+	if err != nil {
+		return nil, err
+	}
+	var ret common.VoteDelegationCertificate
+	err = buildCertificate(
+		&ret,
+		common.CertificateTypeVoteDelegation,
+		cred,
+		drepData,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// StakeVoteDelegation returns a StakeVoteDelegation certificate which delegates the stake credential to a pool and
+// its votes to a DRep
+func (b *CertificateBuilder) StakeVoteDelegation(
+	cred common.StakeCredential,
+	pool common.PoolKeyHash,
+	drep common.Drep,
+) (*common.StakeVoteDelegationCertificate, error) {
+	drepData, err := encodeDrep(drep)
+	if err != nil {
+		return nil, err
+	}
+	var ret common.StakeVoteDelegationCertificate
+	err = buildCertificate(
+		&ret,
+		common.CertificateTypeStakeVoteDelegation,
+		cred,
+		pool,
+		drepData,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// StakeRegistrationDelegation returns a StakeRegistrationDelegation certificate which registers the stake
+// credential with the deposit and delegates it to a pool
+func (b *CertificateBuilder) StakeRegistrationDelegation(
+	cred common.StakeCredential,
+	pool common.PoolKeyHash,
+) (*common.StakeRegistrationDelegationCertificate, error) {
+	var ret common.StakeRegistrationDelegationCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeStakeRegistrationDelegation,
+		cred,
+		pool,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// VoteRegistrationDelegation returns a VoteRegistrationDelegation certificate which registers the stake
+// credential with the deposit and delegates its votes to a DRep
+func (b *CertificateBuilder) VoteRegistrationDelegation(
+	cred common.StakeCredential,
+	drep common.Drep,
+) (*common.VoteRegistrationDelegationCertificate, error) {
+	drepData, err := encodeDrep(drep)
+	if err != nil {
+		return nil, err
+	}
+	var ret common.VoteRegistrationDelegationCertificate
+	err = buildCertificate(
+		&ret,
+		common.CertificateTypeVoteRegistrationDelegation,
+		cred,
+		drepData,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// StakeVoteRegistrationDelegation returns a StakeVoteRegistrationDelegation certificate which registers the stake
+// credential with the deposit, delegates it to a pool, and delegates its votes to a DRep
+func (b *CertificateBuilder) StakeVoteRegistrationDelegation(
+	cred common.StakeCredential,
+	pool common.PoolKeyHash,
+	drep common.Drep,
+) (*common.StakeVoteRegistrationDelegationCertificate, error) {
+	drepData, err := encodeDrep(drep)
+	if err != nil {
+		return nil, err
+	}
+	var ret common.StakeVoteRegistrationDelegationCertificate
+	err = buildCertificate(
+		&ret,
+		common.CertificateTypeStakeVoteRegistrationDelegation,
+		cred,
+		pool,
+		drepData,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// AuthCommitteeHot returns an AuthCommitteeHot certificate which authorizes the hot credential to vote for the
+// cold credential of a constitutional committee member
+func (b *CertificateBuilder) AuthCommitteeHot(
+	coldCred common.StakeCredential,
+	hotCred common.StakeCredential,
+) (*common.AuthCommitteeHotCertificate, error) {
+	var ret common.AuthCommitteeHotCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeAuthCommitteeHot,
+		coldCred,
+		hotCred,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ResignCommitteeCold returns a ResignCommitteeCold certificate for the cold credential of a constitutional
+// committee member with the anchor
+func (b *CertificateBuilder) ResignCommitteeCold(
+	coldCred common.StakeCredential,
+) (*common.ResignCommitteeColdCertificate, error) {
+	var ret common.ResignCommitteeColdCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeResignCommitteeCold,
+		coldCred,
+		b.anchor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// RegistrationDrep returns a RegistrationDrep certificate for the DRep credential with the deposit and anchor
+func (b *CertificateBuilder) RegistrationDrep(
+	drepCred common.StakeCredential,
+) (*common.RegistrationDrepCertificate, error) {
+	var ret common.RegistrationDrepCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeRegistrationDrep,
+		drepCred,
+		b.deposit,
+		b.anchor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// DeregistrationDrep returns a DeregistrationDrep certificate for the DRep credential with the deposit refund
+func (b *CertificateBuilder) DeregistrationDrep(
+	drepCred common.StakeCredential,
+) (*common.DeregistrationDrepCertificate, error) {
+	var ret common.DeregistrationDrepCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeDeregistrationDrep,
+		drepCred,
+		b.deposit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// UpdateDrep returns an UpdateDrep certificate for the DRep credential with the anchor
+func (b *CertificateBuilder) UpdateDrep(
+	drepCred common.StakeCredential,
+) (*common.UpdateDrepCertificate, error) {
+	var ret common.UpdateDrepCertificate
+	err := buildCertificate(
+		&ret,
+		common.CertificateTypeUpdateDrep,
+		drepCred,
+		b.anchor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// buildCertificate encodes a certificate from its type and fields and decodes it into dest, which stores the CBOR
+func buildCertificate(dest any, certType uint, fields ...any) error {
+	certCbor, err := cbor.Encode(append([]any{certType}, fields...))
+	if err != nil {
+		return err
+	}
+	if _, err := cbor.Decode(certCbor, dest); err != nil {
+		return err
+	}
+	return nil
+}
+
+// encodeDrep returns the list representation of a DRep used on the wire
+func encodeDrep(drep common.Drep) ([]any, error) {
+	switch drep.Type {
+	case common.DrepTypeAddrKeyHash, common.DrepTypeScriptHash:
+		return []any{drep.Type, drep.Credential}, nil
+	case common.DrepTypeAbstain, common.DrepTypeNoConfidence:
+		return []any{drep.Type}, nil
+	default:
+		return nil, fmt.Errorf("invalid DRep type: %d", drep.Type)
+	}
+}
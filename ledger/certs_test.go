@@ -0,0 +1,186 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestCertificateBuilder(t *testing.T) {
+	stakeCred := common.StakeCredential{
+		CredType:   common.StakeCredentialTypeAddrKeyHash,
+		Credential: bytes.Repeat([]byte{0x01}, 28),
+	}
+	hotCred := common.StakeCredential{
+		CredType:   common.StakeCredentialTypeAddrKeyHash,
+		Credential: bytes.Repeat([]byte{0x02}, 28),
+	}
+	pool := common.PoolKeyHash{0x03}
+	drep := common.Drep{
+		Type:       common.DrepTypeAddrKeyHash,
+		Credential: bytes.Repeat([]byte{0x04}, 28),
+	}
+	builder := ledger.NewCertificateBuilder().
+		WithDeposit(2_000_000).
+		WithAnchor(common.GovAnchor{Url: "https://example.com/anchor.json"})
+	testDefs := []struct {
+		certType uint
+		build    func() (common.Certificate, error)
+	}{
+		{
+			certType: common.CertificateTypeStakeRegistration,
+			build: func() (common.Certificate, error) {
+				return builder.StakeRegistration(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeStakeDeregistration,
+			build: func() (common.Certificate, error) {
+				return builder.StakeDeregistration(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeStakeDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.StakeDelegation(stakeCred, pool)
+			},
+		},
+		{
+			certType: common.CertificateTypePoolRetirement,
+			build: func() (common.Certificate, error) {
+				return builder.PoolRetirement(pool, 500)
+			},
+		},
+		{
+			certType: common.CertificateTypeRegistration,
+			build: func() (common.Certificate, error) {
+				return builder.Registration(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeDeregistration,
+			build: func() (common.Certificate, error) {
+				return builder.Deregistration(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeVoteDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.VoteDelegation(stakeCred, common.Drep{Type: common.DrepTypeAbstain})
+			},
+		},
+		{
+			certType: common.CertificateTypeStakeVoteDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.StakeVoteDelegation(stakeCred, pool, drep)
+			},
+		},
+		{
+			certType: common.CertificateTypeStakeRegistrationDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.StakeRegistrationDelegation(stakeCred, pool)
+			},
+		},
+		{
+			certType: common.CertificateTypeVoteRegistrationDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.VoteRegistrationDelegation(stakeCred, drep)
+			},
+		},
+		{
+			certType: common.CertificateTypeStakeVoteRegistrationDelegation,
+			build: func() (common.Certificate, error) {
+				return builder.StakeVoteRegistrationDelegation(stakeCred, pool, drep)
+			},
+		},
+		{
+			certType: common.CertificateTypeAuthCommitteeHot,
+			build: func() (common.Certificate, error) {
+				return builder.AuthCommitteeHot(stakeCred, hotCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeResignCommitteeCold,
+			build: func() (common.Certificate, error) {
+				return builder.ResignCommitteeCold(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeRegistrationDrep,
+			build: func() (common.Certificate, error) {
+				return builder.RegistrationDrep(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeDeregistrationDrep,
+			build: func() (common.Certificate, error) {
+				return builder.DeregistrationDrep(stakeCred)
+			},
+		},
+		{
+			certType: common.CertificateTypeUpdateDrep,
+			build: func() (common.Certificate, error) {
+				return builder.UpdateDrep(stakeCred)
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		cert, err := testDef.build()
+		if err != nil {
+			t.Fatalf("unexpected error building certificate type %d: %s", testDef.certType, err)
+		}
+		// The stored CBOR should decode as the same certificate type
+		var tmpCert common.CertificateWrapper
+		if _, err := cbor.Decode(cert.Cbor(), &tmpCert); err != nil {
+			t.Fatalf("unexpected error decoding certificate type %d: %s", testDef.certType, err)
+		}
+		if tmpCert.Type != testDef.certType {
+			t.Fatalf("did not get expected certificate type: got %d, expected %d", tmpCert.Type, testDef.certType)
+		}
+	}
+}
+
+func TestCertificateBuilderDrep(t *testing.T) {
+	stakeCred := common.StakeCredential{
+		CredType:   common.StakeCredentialTypeAddrKeyHash,
+		Credential: make([]byte, 28),
+	}
+	builder := ledger.NewCertificateBuilder().WithDeposit(2_000_000)
+	cert, err := builder.VoteRegistrationDelegation(
+		stakeCred,
+		common.Drep{Type: common.DrepTypeNoConfidence},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building certificate: %s", err)
+	}
+	expectedCbor := "840c8200581c00000000000000000000000000000000000000000000000000000000" +
+		"81031a001e8480"
+	if hex.EncodeToString(cert.Cbor()) != expectedCbor {
+		t.Fatalf("did not get expected CBOR: got %x, expected %s", cert.Cbor(), expectedCbor)
+	}
+	if cert.Drep.Type != common.DrepTypeNoConfidence || cert.Amount != 2_000_000 {
+		t.Fatalf("did not get expected certificate fields: %#v", cert)
+	}
+	if _, err := builder.VoteDelegation(stakeCred, common.Drep{Type: 99}); err == nil {
+		t.Fatalf("did not get expected error for invalid DRep type")
+	}
+}
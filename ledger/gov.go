@@ -0,0 +1,326 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// GovActionBuilder builds governance actions for embedding in transactions
+type GovActionBuilder struct {
+	prevActionId *common.GovActionId
+	policyHash   []byte
+}
+
+// NewGovActionBuilder returns a new GovActionBuilder
+func NewGovActionBuilder() *GovActionBuilder {
+	return &GovActionBuilder{}
+}
+
+// WithPrevActionId specifies the ID of the previously enacted governance action of the same purpose. This is used
+// by the ParameterChange, HardForkInitiation, NoConfidence, UpdateCommittee, and NewConstitution actions
+func (b *GovActionBuilder) WithPrevActionId(
+	txId [32]byte,
+	idx uint32,
+) *GovActionBuilder {
+	b.prevActionId = &common.GovActionId{
+		TransactionId: txId,
+		GovActionIdx:  idx,
+	}
+	return b
+}
+
+// WithPolicyHash specifies the guardrails script hash. This is used by the ParameterChange and
+// TreasuryWithdrawal actions
+func (b *GovActionBuilder) WithPolicyHash(policyHash []byte) *GovActionBuilder {
+	b.policyHash = policyHash
+	return b
+}
+
+// ParameterChange returns a ParameterChange governance action with the provided protocol parameter update. The
+// update is encoded to CBOR unless it's already a cbor.RawMessage
+func (b *GovActionBuilder) ParameterChange(
+	paramUpdate any,
+) (*common.ParameterChangeGovAction, error) {
+	paramUpdateCbor, ok := paramUpdate.(cbor.RawMessage)
+	if !ok {
+		tmpCbor, err := cbor.Encode(paramUpdate)
+		if err != nil {
+			return nil, err
+		}
+		paramUpdateCbor = cbor.RawMessage(tmpCbor)
+	}
+	return &common.ParameterChangeGovAction{
+		Type:        common.GovActionTypeParameterChange,
+		ActionId:    b.prevActionId,
+		ParamUpdate: paramUpdateCbor,
+		PolicyHash:  b.policyHash,
+	}, nil
+}
+
+// HardForkInitiation returns a HardForkInitiation governance action for the provided protocol version
+func (b *GovActionBuilder) HardForkInitiation(
+	major uint,
+	minor uint,
+) *common.HardForkInitiationGovAction {
+	ret := &common.HardForkInitiationGovAction{
+		Type:     common.GovActionTypeHardForkInitiation,
+		ActionId: b.prevActionId,
+	}
+	ret.ProtocolVersion.Major = major
+	ret.ProtocolVersion.Minor = minor
+	return ret
+}
+
+// TreasuryWithdrawal returns a TreasuryWithdrawal governance action for the provided reward addresses and amounts
+func (b *GovActionBuilder) TreasuryWithdrawal(
+	withdrawals map[*common.Address]uint64,
+) *common.TreasuryWithdrawalGovAction {
+	return &common.TreasuryWithdrawalGovAction{
+		Type:        common.GovActionTypeTreasuryWithdrawal,
+		Withdrawals: withdrawals,
+		PolicyHash:  b.policyHash,
+	}
+}
+
+// NoConfidence returns a NoConfidence governance action
+func (b *GovActionBuilder) NoConfidence() *common.NoConfidenceGovAction {
+	return &common.NoConfidenceGovAction{
+		Type:     common.GovActionTypeNoConfidence,
+		ActionId: b.prevActionId,
+	}
+}
+
+// UpdateCommittee returns an UpdateCommittee governance action which removes the provided committee members, adds
+// the provided committee members with their expiration epochs, and sets the quorum threshold. It returns an error if
+// no quorum is provided
+func (b *GovActionBuilder) UpdateCommittee(
+	remove []common.StakeCredential,
+	add map[*common.StakeCredential]uint,
+	quorum *big.Rat,
+) (*common.UpdateCommitteeGovAction, error) {
+	if quorum == nil {
+		return nil, errors.New("no quorum provided")
+	}
+	if remove == nil {
+		remove = []common.StakeCredential{}
+	}
+	if add == nil {
+		add = map[*common.StakeCredential]uint{}
+	}
+	return &common.UpdateCommitteeGovAction{
+		Type:        common.GovActionTypeUpdateCommittee,
+		ActionId:    b.prevActionId,
+		Credentials: remove,
+		CredEpochs:  add,
+		Unknown:     cbor.Rat{Rat: quorum},
+	}, nil
+}
+
+// NewConstitution returns a NewConstitution governance action with the provided anchor and optional guardrails
+// script hash
+func (b *GovActionBuilder) NewConstitution(
+	anchor common.GovAnchor,
+	scriptHash []byte,
+) *common.NewConstitutionGovAction {
+	ret := &common.NewConstitutionGovAction{
+		Type:     common.GovActionTypeNewConstitution,
+		ActionId: b.prevActionId,
+	}
+	ret.Constitution.Anchor = anchor
+	ret.Constitution.ScriptHash = scriptHash
+	return ret
+}
+
+// Info returns an Info governance action
+func (b *GovActionBuilder) Info() *common.InfoGovAction {
+	return &common.InfoGovAction{
+		Type: common.GovActionTypeInfo,
+	}
+}
+
+// VotingProceduresBuilder builds the voting procedures for a transaction, which map each voter to its votes on
+// governance actions
+type VotingProceduresBuilder struct {
+	votes map[common.Voter]map[common.GovActionId]common.VotingProcedure
+}
+
+// NewVotingProceduresBuilder returns a new VotingProceduresBuilder
+func NewVotingProceduresBuilder() *VotingProceduresBuilder {
+	return &VotingProceduresBuilder{
+		votes: make(map[common.Voter]map[common.GovActionId]common.VotingProcedure),
+	}
+}
+
+// Add adds a vote from the voter on the governance action with an optional anchor. It returns an error if the
+// voter type or vote is invalid, or if the voter has already voted on the action
+func (b *VotingProceduresBuilder) Add(
+	voter common.Voter,
+	actionId common.GovActionId,
+	vote uint8,
+	anchor *common.GovAnchor,
+) error {
+	if voter.Type > common.VoterTypeStakingPoolKeyHash {
+		return fmt.Errorf("invalid voter type: %d", voter.Type)
+	}
+	if vote > common.GovVoteAbstain {
+		return fmt.Errorf("invalid vote: %d", vote)
+	}
+	voterVotes, ok := b.votes[voter]
+	if !ok {
+		voterVotes = make(map[common.GovActionId]common.VotingProcedure)
+		b.votes[voter] = voterVotes
+	}
+	if _, ok := voterVotes[actionId]; ok {
+		return fmt.Errorf(
+			"duplicate vote from voter %x on governance action %x#%d",
+			voter.Hash,
+			actionId.TransactionId,
+			actionId.GovActionIdx,
+		)
+	}
+	voterVotes[actionId] = common.VotingProcedure{
+		Vote:   vote,
+		Anchor: anchor,
+	}
+	return nil
+}
+
+// Build returns the voting procedures. It returns an error if no votes were added, since the voting procedures
+// must not be empty when present in a transaction
+func (b *VotingProceduresBuilder) Build() (common.VotingProcedures, error) {
+	if len(b.votes) == 0 {
+		return nil, errors.New("no votes added")
+	}
+	// Copy the data so that later calls to Add don't modify the returned value
+	ret := make(common.VotingProcedures, len(b.votes))
+	for voter, voterVotes := range b.votes {
+		tmpVotes := make(map[*common.GovActionId]common.VotingProcedure, len(voterVotes))
+		for actionId, procedure := range voterVotes {
+			tmpVotes[&actionId] = procedure
+		}
+		ret[&voter] = tmpVotes
+	}
+	return ret, nil
+}
+
+// ProposalProcedureBuilder builds a proposal procedure, which submits a governance action in a transaction
+type ProposalProcedureBuilder struct {
+	deposit       uint64
+	rewardAccount *common.Address
+	govAction     common.GovAction
+	anchor        *common.GovAnchor
+}
+
+// NewProposalProcedureBuilder returns a new ProposalProcedureBuilder
+func NewProposalProcedureBuilder() *ProposalProcedureBuilder {
+	return &ProposalProcedureBuilder{}
+}
+
+// WithDeposit specifies the governance action deposit, which should match the current protocol parameters
+func (b *ProposalProcedureBuilder) WithDeposit(
+	amount uint64,
+) *ProposalProcedureBuilder {
+	b.deposit = amount
+	return b
+}
+
+// WithRewardAccount specifies the stake address which receives the deposit when the governance action is
+// enacted or expires
+func (b *ProposalProcedureBuilder) WithRewardAccount(
+	addr common.Address,
+) *ProposalProcedureBuilder {
+	b.rewardAccount = &addr
+	return b
+}
+
+// WithGovAction specifies the governance action, as returned by GovActionBuilder
+func (b *ProposalProcedureBuilder) WithGovAction(
+	action common.GovAction,
+) *ProposalProcedureBuilder {
+	b.govAction = action
+	return b
+}
+
+// WithAnchor specifies the metadata anchor for the proposal
+func (b *ProposalProcedureBuilder) WithAnchor(
+	anchor common.GovAnchor,
+) *ProposalProcedureBuilder {
+	b.anchor = &anchor
+	return b
+}
+
+// Build returns the proposal procedure. It returns an error if the reward account, governance action, or anchor
+// weren't provided, or if the reward account isn't a stake address
+func (b *ProposalProcedureBuilder) Build() (*common.ProposalProcedure, error) {
+	if b.rewardAccount == nil {
+		return nil, errors.New("no reward account provided")
+	}
+	addrBytes := b.rewardAccount.Bytes()
+	if len(addrBytes) == 0 ||
+		(addrBytes[0]>>4 != common.AddressTypeNoneKey &&
+			addrBytes[0]>>4 != common.AddressTypeNoneScript) {
+		return nil, fmt.Errorf(
+			"reward account is not a stake address: %s",
+			b.rewardAccount.String(),
+		)
+	}
+	if b.govAction == nil {
+		return nil, errors.New("no governance action provided")
+	}
+	actionType, err := govActionType(b.govAction)
+	if err != nil {
+		return nil, err
+	}
+	if b.anchor == nil {
+		return nil, errors.New("no anchor provided")
+	}
+	return &common.ProposalProcedure{
+		Deposit:       b.deposit,
+		RewardAccount: *b.rewardAccount,
+		GovAction: common.GovActionWrapper{
+			Type:   actionType,
+			Action: b.govAction,
+		},
+		Anchor: *b.anchor,
+	}, nil
+}
+
+// govActionType returns the type ID for the provided governance action
+func govActionType(action common.GovAction) (uint, error) {
+	switch action.(type) {
+	case *common.ParameterChangeGovAction:
+		return common.GovActionTypeParameterChange, nil
+	case *common.HardForkInitiationGovAction:
+		return common.GovActionTypeHardForkInitiation, nil
+	case *common.TreasuryWithdrawalGovAction:
+		return common.GovActionTypeTreasuryWithdrawal, nil
+	case *common.NoConfidenceGovAction:
+		return common.GovActionTypeNoConfidence, nil
+	case *common.UpdateCommitteeGovAction:
+		return common.GovActionTypeUpdateCommittee, nil
+	case *common.NewConstitutionGovAction:
+		return common.GovActionTypeNewConstitution, nil
+	case *common.InfoGovAction:
+		return common.GovActionTypeInfo, nil
+	default:
+		return 0, fmt.Errorf("unsupported governance action: %T", action)
+	}
+}
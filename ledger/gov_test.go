@@ -0,0 +1,252 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+const testStakeAddress = "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"
+
+func TestGovActionBuilder(t *testing.T) {
+	rewardAddr, err := common.NewAddress(testStakeAddress)
+	if err != nil {
+		t.Fatalf("unexpected error decoding address: %s", err)
+	}
+	committeeMember := common.StakeCredential{
+		CredType:   common.StakeCredentialTypeAddrKeyHash,
+		Credential: make([]byte, 28),
+	}
+	builder := ledger.NewGovActionBuilder().
+		WithPrevActionId([32]byte{0x01}, 2).
+		WithPolicyHash(make([]byte, 28))
+	paramChange, err := builder.ParameterChange(
+		map[uint]any{0: uint64(44)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building parameter change: %s", err)
+	}
+	updateCommittee, err := builder.UpdateCommittee(
+		nil,
+		map[*common.StakeCredential]uint{&committeeMember: 500},
+		big.NewRat(2, 3),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building update committee: %s", err)
+	}
+	testDefs := []struct {
+		action       common.GovAction
+		expectedType uint
+	}{
+		{
+			action:       paramChange,
+			expectedType: common.GovActionTypeParameterChange,
+		},
+		{
+			action:       builder.HardForkInitiation(10, 0),
+			expectedType: common.GovActionTypeHardForkInitiation,
+		},
+		{
+			action: builder.TreasuryWithdrawal(
+				map[*common.Address]uint64{&rewardAddr: 1_000_000},
+			),
+			expectedType: common.GovActionTypeTreasuryWithdrawal,
+		},
+		{
+			action:       builder.NoConfidence(),
+			expectedType: common.GovActionTypeNoConfidence,
+		},
+		{
+			action:       updateCommittee,
+			expectedType: common.GovActionTypeUpdateCommittee,
+		},
+		{
+			action: builder.NewConstitution(
+				common.GovAnchor{Url: "https://example.com/constitution"},
+				nil,
+			),
+			expectedType: common.GovActionTypeNewConstitution,
+		},
+		{
+			action:       builder.Info(),
+			expectedType: common.GovActionTypeInfo,
+		},
+	}
+	for _, testDef := range testDefs {
+		actionCbor, err := cbor.Encode(testDef.action)
+		if err != nil {
+			t.Fatalf("unexpected error encoding %T: %s", testDef.action, err)
+		}
+		var wrapper common.GovActionWrapper
+		if _, err := cbor.Decode(actionCbor, &wrapper); err != nil {
+			t.Fatalf("unexpected error decoding %T: %s", testDef.action, err)
+		}
+		if wrapper.Type != testDef.expectedType {
+			t.Fatalf("did not get expected action type: got %d, expected %d", wrapper.Type, testDef.expectedType)
+		}
+	}
+}
+
+func TestGovActionBuilderPrevActionId(t *testing.T) {
+	action := ledger.NewGovActionBuilder().
+		WithPrevActionId([32]byte{0xab}, 3).
+		NoConfidence()
+	actionCbor, err := cbor.Encode(action)
+	if err != nil {
+		t.Fatalf("unexpected error encoding action: %s", err)
+	}
+	var decoded common.NoConfidenceGovAction
+	if _, err := cbor.Decode(actionCbor, &decoded); err != nil {
+		t.Fatalf("unexpected error decoding action: %s", err)
+	}
+	if decoded.ActionId == nil {
+		t.Fatalf("decoded action did not have previous action ID")
+	}
+	if decoded.ActionId.TransactionId[0] != 0xab || decoded.ActionId.GovActionIdx != 3 {
+		t.Fatalf("did not get expected previous action ID: %#v", decoded.ActionId)
+	}
+}
+
+func TestGovActionBuilderUpdateCommitteeNoQuorum(t *testing.T) {
+	if _, err := ledger.NewGovActionBuilder().UpdateCommittee(nil, nil, nil); err == nil {
+		t.Fatalf("did not get expected error building update committee without quorum")
+	}
+}
+
+func TestVotingProceduresBuilder(t *testing.T) {
+	drepVoter := common.Voter{
+		Type: common.VoterTypeDRepKeyHash,
+		Hash: [28]byte{0x01},
+	}
+	poolVoter := common.Voter{
+		Type: common.VoterTypeStakingPoolKeyHash,
+		Hash: [28]byte{0x02},
+	}
+	action1 := common.GovActionId{TransactionId: [32]byte{0xaa}, GovActionIdx: 0}
+	action2 := common.GovActionId{TransactionId: [32]byte{0xaa}, GovActionIdx: 1}
+	builder := ledger.NewVotingProceduresBuilder()
+	if _, err := builder.Build(); err == nil {
+		t.Fatalf("did not get expected error building empty voting procedures")
+	}
+	anchor := &common.GovAnchor{Url: "https://example.com/rationale.json"}
+	if err := builder.Add(drepVoter, action1, common.GovVoteYes, anchor); err != nil {
+		t.Fatalf("unexpected error adding vote: %s", err)
+	}
+	if err := builder.Add(drepVoter, action2, common.GovVoteNo, nil); err != nil {
+		t.Fatalf("unexpected error adding vote: %s", err)
+	}
+	if err := builder.Add(poolVoter, action1, common.GovVoteAbstain, nil); err != nil {
+		t.Fatalf("unexpected error adding vote: %s", err)
+	}
+	if err := builder.Add(drepVoter, action1, common.GovVoteNo, nil); err == nil {
+		t.Fatalf("did not get expected error adding duplicate vote")
+	}
+	if err := builder.Add(common.Voter{Type: 5}, action1, common.GovVoteYes, nil); err == nil {
+		t.Fatalf("did not get expected error adding vote with invalid voter type")
+	}
+	if err := builder.Add(poolVoter, action2, 3, nil); err == nil {
+		t.Fatalf("did not get expected error adding invalid vote")
+	}
+	procedures, err := builder.Build()
+	if err != nil {
+		t.Fatalf("unexpected error building voting procedures: %s", err)
+	}
+	proceduresCbor, err := cbor.Encode(procedures)
+	if err != nil {
+		t.Fatalf("unexpected error encoding voting procedures: %s", err)
+	}
+	var decoded common.VotingProcedures
+	if _, err := cbor.Decode(proceduresCbor, &decoded); err != nil {
+		t.Fatalf("unexpected error decoding voting procedures: %s", err)
+	}
+	votes := map[common.Voter]map[common.GovActionId]uint8{}
+	for voter, voterVotes := range decoded {
+		votes[*voter] = map[common.GovActionId]uint8{}
+		for actionId, procedure := range voterVotes {
+			votes[*voter][*actionId] = procedure.Vote
+		}
+	}
+	expectedVotes := map[common.Voter]map[common.GovActionId]uint8{
+		drepVoter: {
+			action1: common.GovVoteYes,
+			action2: common.GovVoteNo,
+		},
+		poolVoter: {
+			action1: common.GovVoteAbstain,
+		},
+	}
+	if !reflect.DeepEqual(votes, expectedVotes) {
+		t.Fatalf("did not get expected votes: got %v, expected %v", votes, expectedVotes)
+	}
+}
+
+func TestProposalProcedureBuilder(t *testing.T) {
+	rewardAddr, err := common.NewAddress(testStakeAddress)
+	if err != nil {
+		t.Fatalf("unexpected error decoding address: %s", err)
+	}
+	anchor := common.GovAnchor{
+		Url:      "https://example.com/proposal.json",
+		DataHash: [32]byte{0x01},
+	}
+	builder := ledger.NewProposalProcedureBuilder().
+		WithDeposit(100_000_000_000).
+		WithAnchor(anchor)
+	if _, err := builder.Build(); err == nil {
+		t.Fatalf("did not get expected error building without reward account")
+	}
+	// A base address isn't a valid reward account
+	persona, err := ledger.NewAddressGenerator(common.AddressNetworkMainnet, 1).NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	builder.WithRewardAccount(persona.BaseAddress)
+	builder.WithGovAction(ledger.NewGovActionBuilder().Info())
+	if _, err := builder.Build(); err == nil {
+		t.Fatalf("did not get expected error building with base address reward account")
+	}
+	builder.WithRewardAccount(rewardAddr)
+	proposal, err := builder.Build()
+	if err != nil {
+		t.Fatalf("unexpected error building proposal procedure: %s", err)
+	}
+	proposalCbor, err := cbor.Encode(proposal)
+	if err != nil {
+		t.Fatalf("unexpected error encoding proposal procedure: %s", err)
+	}
+	var decoded common.ProposalProcedure
+	if _, err := cbor.Decode(proposalCbor, &decoded); err != nil {
+		t.Fatalf("unexpected error decoding proposal procedure: %s", err)
+	}
+	if decoded.Deposit != 100_000_000_000 {
+		t.Fatalf("did not get expected deposit: got %d", decoded.Deposit)
+	}
+	if decoded.RewardAccount.String() != testStakeAddress {
+		t.Fatalf("did not get expected reward account: got %s, expected %s", decoded.RewardAccount.String(), testStakeAddress)
+	}
+	if decoded.GovAction.Type != common.GovActionTypeInfo {
+		t.Fatalf("did not get expected action type: got %d, expected %d", decoded.GovAction.Type, common.GovActionTypeInfo)
+	}
+	if !reflect.DeepEqual(decoded.Anchor, anchor) {
+		t.Fatalf("did not get expected anchor: got %#v, expected %#v", decoded.Anchor, anchor)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// VkeyWitness is a verification key and signature of a transaction body, as included in a transaction witness set
+type VkeyWitness struct {
+	cbor.StructAsArray
+	Vkey      []byte
+	Signature []byte
+}
+
+// NewKeyFromSeed returns the ed25519 signing key for the provided 32-byte seed
+func NewKeyFromSeed(seed []byte) (ed25519.PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf(
+			"invalid seed length: expected %d bytes, got %d",
+			ed25519.SeedSize,
+			len(seed),
+		)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// VkeyHash returns the hash of the provided verification key, as used in addresses and required signers
+func VkeyHash(vkey ed25519.PublicKey) common.Blake2b224 {
+	return common.Blake2b224Hash(vkey)
+}
+
+// TxBodyHash returns the hash of the provided transaction body CBOR, which is also the transaction ID
+func TxBodyHash(txBodyCbor []byte) common.Blake2b256 {
+	return common.Blake2b256Hash(txBodyCbor)
+}
+
+// SignTxBody returns a witness for the provided transaction body CBOR signed with the provided key
+func SignTxBody(key ed25519.PrivateKey, txBodyCbor []byte) VkeyWitness {
+	txBodyHash := TxBodyHash(txBodyCbor)
+	return VkeyWitness{
+		Vkey:      key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, txBodyHash.Bytes()),
+	}
+}
+
+// VerifyVkeyWitness reports whether the provided witness has a valid signature for the provided transaction body
+// CBOR
+func VerifyVkeyWitness(witness VkeyWitness, txBodyCbor []byte) bool {
+	if len(witness.Vkey) != ed25519.PublicKeySize {
+		return false
+	}
+	txBodyHash := TxBodyHash(txBodyCbor)
+	return ed25519.Verify(witness.Vkey, txBodyHash.Bytes(), witness.Signature)
+}
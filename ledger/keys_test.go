@@ -0,0 +1,81 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestNewKeyFromSeed(t *testing.T) {
+	// Test vector from RFC 8032
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	expectedVkey, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	key, err := ledger.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("unexpected error creating key: %s", err)
+	}
+	vkey := key.Public().(ed25519.PublicKey)
+	if !bytes.Equal(vkey, expectedVkey) {
+		t.Fatalf("did not get expected vkey: got %x, expected %x", vkey, expectedVkey)
+	}
+	if ledger.VkeyHash(vkey) != common.Blake2b224Hash(expectedVkey) {
+		t.Fatalf("did not get expected vkey hash: %s", ledger.VkeyHash(vkey))
+	}
+	if _, err := ledger.NewKeyFromSeed(seed[:16]); err == nil {
+		t.Fatalf("did not receive expected error for short seed")
+	}
+}
+
+func TestSignTxBody(t *testing.T) {
+	key, err := ledger.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	if err != nil {
+		t.Fatalf("unexpected error creating key: %s", err)
+	}
+	txBodyCbor, err := cbor.Encode(map[uint]any{2: uint64(200000)})
+	if err != nil {
+		t.Fatalf("unexpected error encoding tx body: %s", err)
+	}
+	witness := ledger.SignTxBody(key, txBodyCbor)
+	if !ledger.VerifyVkeyWitness(witness, txBodyCbor) {
+		t.Fatalf("witness did not verify")
+	}
+	otherTxBodyCbor, err := cbor.Encode(map[uint]any{2: uint64(200001)})
+	if err != nil {
+		t.Fatalf("unexpected error encoding tx body: %s", err)
+	}
+	if ledger.VerifyVkeyWitness(witness, otherTxBodyCbor) {
+		t.Fatalf("witness should not verify for a different tx body")
+	}
+	// Witnesses are encoded as a vkey and signature pair
+	witnessCbor, err := cbor.Encode(&witness)
+	if err != nil {
+		t.Fatalf("unexpected error encoding witness: %s", err)
+	}
+	var tmpWitness [][]byte
+	if _, err := cbor.Decode(witnessCbor, &tmpWitness); err != nil {
+		t.Fatalf("unexpected error decoding witness: %s", err)
+	}
+	if len(tmpWitness) != 2 || !bytes.Equal(tmpWitness[0], witness.Vkey) || !bytes.Equal(tmpWitness[1], witness.Signature) {
+		t.Fatalf("did not get expected witness encoding: %x", witnessCbor)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"math/big"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// RewardParams are the protocol parameters used in the reward calculation
+type RewardParams struct {
+	// Rho is the monetary expansion rate
+	Rho *big.Rat
+	// Tau is the treasury growth rate
+	Tau *big.Rat
+	// A0 is the pledge influence factor
+	A0 *big.Rat
+	// NOpt is the target number of pools (k)
+	NOpt uint
+}
+
+// RewardEpoch holds the chain-wide values for the epoch being rewarded
+type RewardEpoch struct {
+	// PoolPot is the amount available for pool rewards, as returned by RewardParams.RewardPot
+	PoolPot uint64
+	// TotalStake is the circulating supply
+	TotalStake uint64
+	// ActiveStake is the total delegated stake
+	ActiveStake uint64
+	// TotalBlocks is the number of blocks made by pools in the epoch
+	TotalBlocks uint64
+}
+
+// PoolRewardInput holds the values for a single pool in the reward calculation
+type PoolRewardInput struct {
+	Cost   uint64
+	Margin *big.Rat
+	Pledge uint64
+	// OwnerStake is the stake delegated by the pool owners, which must cover the pledge
+	OwnerStake uint64
+	// MemberStake is the stake delegated by each non-owner member, keyed by stake key hash
+	MemberStake map[common.Blake2b224]uint64
+	BlocksMade  uint64
+}
+
+// PoolRewards is the result of the reward calculation for a single pool
+type PoolRewards struct {
+	Leader  uint64
+	Members map[common.Blake2b224]uint64
+}
+
+// RewardPot returns the amount available for pool rewards and the amount moved to the treasury for the provided
+// reserves and fees collected in the epoch
+func (p RewardParams) RewardPot(
+	reserves uint64,
+	fees uint64,
+) (uint64, uint64) {
+	deltaR := floorRat(mulRat(ratOrZero(p.Rho), ratFromUint(reserves)))
+	rewardPot := fees + deltaR
+	deltaT := floorRat(mulRat(ratOrZero(p.Tau), ratFromUint(rewardPot)))
+	return rewardPot - deltaT, deltaT
+}
+
+// PoolRewards returns the leader and member rewards for a pool using the Shelley reward formula
+func (p RewardParams) PoolRewards(
+	epoch RewardEpoch,
+	pool PoolRewardInput,
+) PoolRewards {
+	ret := PoolRewards{
+		Members: make(map[common.Blake2b224]uint64, len(pool.MemberStake)),
+	}
+	poolStake := pool.OwnerStake
+	for _, stake := range pool.MemberStake {
+		poolStake += stake
+	}
+	if epoch.TotalStake == 0 || epoch.ActiveStake == 0 || poolStake == 0 {
+		return ret
+	}
+	// Pools that don't meet their pledge receive no rewards
+	if pool.OwnerStake < pool.Pledge {
+		return ret
+	}
+	totalStake := ratFromUint(epoch.TotalStake)
+	sigma := new(big.Rat).Quo(ratFromUint(poolStake), totalStake)
+	pledge := new(big.Rat).Quo(ratFromUint(pool.Pledge), totalStake)
+	optimalReward := p.maxPool(ratFromUint(epoch.PoolPot), sigma, pledge)
+	// Apparent performance is the share of blocks made relative to the share of active stake
+	performance := new(big.Rat)
+	if epoch.TotalBlocks > 0 {
+		performance.Quo(
+			new(big.Rat).Quo(
+				ratFromUint(pool.BlocksMade),
+				ratFromUint(epoch.TotalBlocks),
+			),
+			new(big.Rat).Quo(
+				ratFromUint(poolStake),
+				ratFromUint(epoch.ActiveStake),
+			),
+		)
+	}
+	poolReward := floorRat(mulRat(performance, optimalReward))
+	if poolReward <= pool.Cost {
+		ret.Leader = poolReward
+		return ret
+	}
+	margin := ratOrZero(pool.Margin)
+	profit := ratFromUint(poolReward - pool.Cost)
+	oneMinusMargin := new(big.Rat).Sub(big.NewRat(1, 1), margin)
+	// The leader receives the cost, the margin, and the owner share of the remainder
+	ownerShare := new(big.Rat).Quo(
+		ratFromUint(pool.OwnerStake),
+		ratFromUint(poolStake),
+	)
+	ret.Leader = pool.Cost + floorRat(
+		mulRat(
+			profit,
+			new(big.Rat).Add(margin, mulRat(oneMinusMargin, ownerShare)),
+		),
+	)
+	for keyHash, stake := range pool.MemberStake {
+		memberShare := new(big.Rat).Quo(
+			ratFromUint(stake),
+			ratFromUint(poolStake),
+		)
+		ret.Members[keyHash] = floorRat(
+			mulRat(profit, mulRat(oneMinusMargin, memberShare)),
+		)
+	}
+	return ret
+}
+
+// maxPool returns the optimal reward for a pool with the provided relative stake and pledge
+func (p RewardParams) maxPool(
+	poolPot *big.Rat,
+	sigma *big.Rat,
+	pledge *big.Rat,
+) *big.Rat {
+	if p.NOpt == 0 {
+		return new(big.Rat)
+	}
+	a0 := ratOrZero(p.A0)
+	z0 := big.NewRat(1, int64(p.NOpt))
+	sigmaP := minRat(sigma, z0)
+	pledgeP := minRat(pledge, z0)
+	// R / (1 + a0) * (sigma' + s' * a0 * (sigma' - s' * (z0 - sigma') / z0) / z0)
+	inner := new(big.Rat).Sub(
+		sigmaP,
+		new(big.Rat).Quo(
+			mulRat(pledgeP, new(big.Rat).Sub(z0, sigmaP)),
+			z0,
+		),
+	)
+	factor := new(big.Rat).Add(
+		sigmaP,
+		new(big.Rat).Quo(mulRat(mulRat(pledgeP, a0), inner), z0),
+	)
+	return mulRat(
+		new(big.Rat).Quo(poolPot, new(big.Rat).Add(big.NewRat(1, 1), a0)),
+		factor,
+	)
+}
+
+func ratFromUint(val uint64) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).SetUint64(val))
+}
+
+// ratOrZero returns the provided value, or zero if it's not set
+func ratOrZero(val *big.Rat) *big.Rat {
+	if val == nil {
+		return new(big.Rat)
+	}
+	return val
+}
+
+func mulRat(a *big.Rat, b *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(a, b)
+}
+
+func minRat(a *big.Rat, b *big.Rat) *big.Rat {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
+
+// floorRat returns the provided non-negative value rounded down to a whole number
+func floorRat(val *big.Rat) uint64 {
+	return new(big.Int).Quo(val.Num(), val.Denom()).Uint64()
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+var testRewardParams = ledger.RewardParams{
+	Rho:  big.NewRat(3, 1000),
+	Tau:  big.NewRat(1, 5),
+	A0:   big.NewRat(3, 10),
+	NOpt: 500,
+}
+
+func TestRewardPot(t *testing.T) {
+	poolPot, treasury := testRewardParams.RewardPot(10_000_000_000, 1_000_000)
+	if poolPot != 24_800_000 {
+		t.Fatalf("did not get expected pool pot: got %d, expected %d", poolPot, 24_800_000)
+	}
+	if treasury != 6_200_000 {
+		t.Fatalf("did not get expected treasury amount: got %d, expected %d", treasury, 6_200_000)
+	}
+}
+
+func TestPoolRewards(t *testing.T) {
+	member := common.NewBlake2b224(make([]byte, 28))
+	epoch := ledger.RewardEpoch{
+		PoolPot:     24_800_000,
+		TotalStake:  1_000_000_000,
+		ActiveStake: 1_000_000_000,
+		TotalBlocks: 1000,
+	}
+	pool := ledger.PoolRewardInput{
+		Cost:   340,
+		Margin: big.NewRat(1, 100),
+		MemberStake: map[common.Blake2b224]uint64{
+			member: 1_000_000,
+		},
+		BlocksMade: 1,
+	}
+	rewards := testRewardParams.PoolRewards(epoch, pool)
+	if rewards.Leader != 527 {
+		t.Fatalf("did not get expected leader reward: got %d, expected %d", rewards.Leader, 527)
+	}
+	if rewards.Members[member] != 18548 {
+		t.Fatalf("did not get expected member reward: got %d, expected %d", rewards.Members[member], 18548)
+	}
+	// The leader receives everything when the reward doesn't exceed the cost
+	pool.Cost = 20_000
+	rewards = testRewardParams.PoolRewards(epoch, pool)
+	if rewards.Leader != 19076 || rewards.Members[member] != 0 {
+		t.Fatalf("did not get expected rewards below cost: %#v", rewards)
+	}
+	// Pools that don't meet their pledge receive nothing
+	pool.Pledge = 1
+	rewards = testRewardParams.PoolRewards(epoch, pool)
+	if rewards.Leader != 0 || rewards.Members[member] != 0 {
+		t.Fatalf("did not get expected rewards for unmet pledge: %#v", rewards)
+	}
+}
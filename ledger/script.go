@@ -0,0 +1,219 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+const (
+	NativeScriptTypePubkey           = 0
+	NativeScriptTypeAll              = 1
+	NativeScriptTypeAny              = 2
+	NativeScriptTypeNofK             = 3
+	NativeScriptTypeInvalidBefore    = 4
+	NativeScriptTypeInvalidHereafter = 5
+)
+
+// nativeScriptHashPrefix is the language tag prepended to native scripts when computing their hash
+const nativeScriptHashPrefix = 0x00
+
+// NativeScript is a multi-signature and timelock script, as used in script addresses and minting policies.
+// Native scripts are built with the NativeScriptXxx functions below
+type NativeScript struct {
+	Type     uint
+	KeyHash  common.Blake2b224
+	Scripts  []NativeScript
+	Required uint
+	Slot     uint64
+}
+
+// NativeScriptContext is the transaction context that a native script is evaluated against
+type NativeScriptContext struct {
+	// Signers are the hashes of the verification keys that witnessed the transaction
+	Signers []common.Blake2b224
+	// ValidityIntervalStart is the first slot in which the transaction is valid, with 0 meaning unbounded
+	ValidityIntervalStart uint64
+	// Ttl is the slot from which the transaction is no longer valid, with 0 meaning unbounded
+	Ttl uint64
+}
+
+// NativeScriptSig returns a native script which requires a signature from the provided key hash
+func NativeScriptSig(keyHash common.Blake2b224) NativeScript {
+	return NativeScript{
+		Type:    NativeScriptTypePubkey,
+		KeyHash: keyHash,
+	}
+}
+
+// NativeScriptAll returns a native script which requires all of the provided scripts to succeed
+func NativeScriptAll(scripts ...NativeScript) NativeScript {
+	return NativeScript{
+		Type:    NativeScriptTypeAll,
+		Scripts: scripts,
+	}
+}
+
+// NativeScriptAny returns a native script which requires any of the provided scripts to succeed
+func NativeScriptAny(scripts ...NativeScript) NativeScript {
+	return NativeScript{
+		Type:    NativeScriptTypeAny,
+		Scripts: scripts,
+	}
+}
+
+// NativeScriptAtLeast returns a native script which requires at least the specified number of the provided scripts
+// to succeed
+func NativeScriptAtLeast(required uint, scripts ...NativeScript) NativeScript {
+	return NativeScript{
+		Type:     NativeScriptTypeNofK,
+		Required: required,
+		Scripts:  scripts,
+	}
+}
+
+// NativeScriptAfter returns a native script which requires the transaction to be valid no earlier than the
+// provided slot
+func NativeScriptAfter(slot uint64) NativeScript {
+	return NativeScript{
+		Type: NativeScriptTypeInvalidBefore,
+		Slot: slot,
+	}
+}
+
+// NativeScriptBefore returns a native script which requires the transaction to be invalid from the provided slot
+func NativeScriptBefore(slot uint64) NativeScript {
+	return NativeScript{
+		Type: NativeScriptTypeInvalidHereafter,
+		Slot: slot,
+	}
+}
+
+// Hash returns the script hash, as used in script addresses and as a policy ID
+func (s NativeScript) Hash() (common.Blake2b224, error) {
+	scriptCbor, err := cbor.Encode(&s)
+	if err != nil {
+		return common.Blake2b224{}, err
+	}
+	return common.Blake2b224Hash(
+		append([]byte{nativeScriptHashPrefix}, scriptCbor...),
+	), nil
+}
+
+// Evaluate reports whether the native script succeeds for the provided transaction context
+func (s NativeScript) Evaluate(ctx NativeScriptContext) bool {
+	switch s.Type {
+	case NativeScriptTypePubkey:
+		return slices.Contains(ctx.Signers, s.KeyHash)
+	case NativeScriptTypeAll:
+		for _, script := range s.Scripts {
+			if !script.Evaluate(ctx) {
+				return false
+			}
+		}
+		return true
+	case NativeScriptTypeAny:
+		for _, script := range s.Scripts {
+			if script.Evaluate(ctx) {
+				return true
+			}
+		}
+		return false
+	case NativeScriptTypeNofK:
+		var count uint
+		for _, script := range s.Scripts {
+			if script.Evaluate(ctx) {
+				count++
+			}
+		}
+		return count >= s.Required
+	case NativeScriptTypeInvalidBefore:
+		return ctx.ValidityIntervalStart != 0 &&
+			ctx.ValidityIntervalStart >= s.Slot
+	case NativeScriptTypeInvalidHereafter:
+		return ctx.Ttl != 0 && ctx.Ttl <= s.Slot
+	default:
+		return false
+	}
+}
+
+func (s *NativeScript) MarshalCBOR() ([]byte, error) {
+	var tmpData []any
+	switch s.Type {
+	case NativeScriptTypePubkey:
+		tmpData = []any{s.Type, s.KeyHash.Bytes()}
+	case NativeScriptTypeAll, NativeScriptTypeAny:
+		tmpData = []any{s.Type, s.scriptsForCbor()}
+	case NativeScriptTypeNofK:
+		tmpData = []any{s.Type, s.Required, s.scriptsForCbor()}
+	case NativeScriptTypeInvalidBefore, NativeScriptTypeInvalidHereafter:
+		tmpData = []any{s.Type, s.Slot}
+	default:
+		return nil, fmt.Errorf("unknown native script type: %d", s.Type)
+	}
+	return cbor.Encode(tmpData)
+}
+
+func (s *NativeScript) UnmarshalCBOR(data []byte) error {
+	var tmpData []cbor.RawMessage
+	if _, err := cbor.Decode(data, &tmpData); err != nil {
+		return err
+	}
+	if len(tmpData) < 2 {
+		return fmt.Errorf("invalid native script: too few items")
+	}
+	var tmpScript NativeScript
+	if _, err := cbor.Decode(tmpData[0], &tmpScript.Type); err != nil {
+		return err
+	}
+	var err error
+	switch tmpScript.Type {
+	case NativeScriptTypePubkey:
+		var keyHash []byte
+		if _, err = cbor.Decode(tmpData[1], &keyHash); err == nil {
+			tmpScript.KeyHash = common.NewBlake2b224(keyHash)
+		}
+	case NativeScriptTypeAll, NativeScriptTypeAny:
+		_, err = cbor.Decode(tmpData[1], &tmpScript.Scripts)
+	case NativeScriptTypeNofK:
+		if len(tmpData) < 3 {
+			return fmt.Errorf("invalid native script: too few items")
+		}
+		if _, err = cbor.Decode(tmpData[1], &tmpScript.Required); err == nil {
+			_, err = cbor.Decode(tmpData[2], &tmpScript.Scripts)
+		}
+	case NativeScriptTypeInvalidBefore, NativeScriptTypeInvalidHereafter:
+		_, err = cbor.Decode(tmpData[1], &tmpScript.Slot)
+	default:
+		return fmt.Errorf("unknown native script type: %d", tmpScript.Type)
+	}
+	if err != nil {
+		return err
+	}
+	*s = tmpScript
+	return nil
+}
+
+// scriptsForCbor returns the sub-scripts, which are always encoded as a list even when empty
+func (s *NativeScript) scriptsForCbor() []NativeScript {
+	if s.Scripts == nil {
+		return []NativeScript{}
+	}
+	return s.Scripts
+}
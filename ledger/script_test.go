@@ -0,0 +1,138 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ledger_test
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/ledger"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestNativeScriptCbor(t *testing.T) {
+	keyHash := common.NewBlake2b224(make([]byte, 28))
+	script := ledger.NativeScriptAll(
+		ledger.NativeScriptSig(keyHash),
+		ledger.NativeScriptAtLeast(1, ledger.NativeScriptAfter(100)),
+		ledger.NativeScriptBefore(200),
+	)
+	scriptCbor, err := cbor.Encode(&script)
+	if err != nil {
+		t.Fatalf("unexpected error encoding script: %s", err)
+	}
+	expectedCbor := "8201838200581c00000000000000000000000000000000000000000000000000000000" +
+		"8303018182041864820518c8"
+	if hex.EncodeToString(scriptCbor) != expectedCbor {
+		t.Fatalf("did not get expected CBOR: got %x, expected %s", scriptCbor, expectedCbor)
+	}
+	var tmpScript ledger.NativeScript
+	if _, err := cbor.Decode(scriptCbor, &tmpScript); err != nil {
+		t.Fatalf("unexpected error decoding script: %s", err)
+	}
+	if !reflect.DeepEqual(tmpScript, script) {
+		t.Fatalf("did not get expected script after round trip: got %#v, expected %#v", tmpScript, script)
+	}
+	scriptHash, err := script.Hash()
+	if err != nil {
+		t.Fatalf("unexpected error hashing script: %s", err)
+	}
+	expectedHash := common.Blake2b224Hash(append([]byte{0x00}, scriptCbor...))
+	if scriptHash != expectedHash {
+		t.Fatalf("did not get expected script hash: got %s, expected %s", scriptHash, expectedHash)
+	}
+}
+
+func TestNativeScriptEvaluate(t *testing.T) {
+	gen := ledger.NewAddressGenerator(common.AddressNetworkTestnet, 1)
+	alice, err := gen.NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	bob, err := gen.NewPersona()
+	if err != nil {
+		t.Fatalf("unexpected error generating persona: %s", err)
+	}
+	// Alice can spend at any time, and Bob can spend from slot 1000
+	script := ledger.NativeScriptAny(
+		ledger.NativeScriptSig(alice.PaymentKeyHash),
+		ledger.NativeScriptAll(
+			ledger.NativeScriptSig(bob.PaymentKeyHash),
+			ledger.NativeScriptAfter(1000),
+		),
+	)
+	testDefs := []struct {
+		name     string
+		ctx      ledger.NativeScriptContext
+		expected bool
+	}{
+		{
+			name: "alice",
+			ctx: ledger.NativeScriptContext{
+				Signers: []common.Blake2b224{alice.PaymentKeyHash},
+			},
+			expected: true,
+		},
+		{
+			name: "bob without validity start",
+			ctx: ledger.NativeScriptContext{
+				Signers: []common.Blake2b224{bob.PaymentKeyHash},
+			},
+		},
+		{
+			name: "bob too early",
+			ctx: ledger.NativeScriptContext{
+				Signers:               []common.Blake2b224{bob.PaymentKeyHash},
+				ValidityIntervalStart: 999,
+			},
+		},
+		{
+			name: "bob",
+			ctx: ledger.NativeScriptContext{
+				Signers:               []common.Blake2b224{bob.PaymentKeyHash},
+				ValidityIntervalStart: 1000,
+			},
+			expected: true,
+		},
+		{
+			name: "no signers",
+			ctx: ledger.NativeScriptContext{
+				ValidityIntervalStart: 1000,
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		if result := script.Evaluate(testDef.ctx); result != testDef.expected {
+			t.Fatalf("%s: did not get expected result: got %v, expected %v", testDef.name, result, testDef.expected)
+		}
+	}
+	// TTL must be set and no later than the slot
+	before := ledger.NativeScriptBefore(500)
+	if before.Evaluate(ledger.NativeScriptContext{}) {
+		t.Fatalf("before script should fail without TTL")
+	}
+	if !before.Evaluate(ledger.NativeScriptContext{Ttl: 500}) {
+		t.Fatalf("before script should succeed with TTL at slot")
+	}
+	if before.Evaluate(ledger.NativeScriptContext{Ttl: 501}) {
+		t.Fatalf("before script should fail with TTL after slot")
+	}
+	if !ledger.NativeScriptAtLeast(0).Evaluate(ledger.NativeScriptContext{}) {
+		t.Fatalf("at least 0 script should always succeed")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// ErrStateExpired is reported by the mock connection when a client sends a query for an acquired state that has
+// expired without reacquiring
+var ErrStateExpired = errors.New("query received for expired acquired state")
+
+// AcquireExpiry specifies when an acquired state expires. A zero value disables the corresponding limit
+type AcquireExpiry struct {
+	// MaxQueries is the number of queries that are answered before the state expires
+	MaxQueries int
+	// Lifetime is the time after the state is acquired when it expires
+	Lifetime time.Duration
+}
+
+// NewConversationEntryExpiringState returns a conversation entry that serves a client which acquires states and
+// queries them, where each acquired state expires as specified. Queries are answered with the provided results in
+// order, regardless of the query. Once a state expires, reacquiring the same point fails with
+// AcquireFailurePointTooOld and a query fails the conversation with ErrStateExpired, so a client must acquire a
+// new point or one of the tips to continue. The entry keeps state, so it should be created per connection with
+// ouroboros_mock.WithConversationFunc
+func NewConversationEntryExpiringState(
+	expiry AcquireExpiry,
+	results ...ouroboros_mock.ConversationEntryOutput,
+) ouroboros_mock.ConversationEntryLoop {
+	state := &expiringState{
+		expiry:  expiry,
+		results: results,
+	}
+	return ouroboros_mock.ConversationEntryLoop{
+		Entries: []ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryResponder{
+				ProtocolId:      localstatequery.ProtocolId,
+				MsgFromCborFunc: newMsgFromCbor,
+				ResponseFunc:    state.respond,
+			},
+		},
+	}
+}
+
+// expiringState tracks the acquired state for NewConversationEntryExpiringState
+type expiringState struct {
+	expiry     AcquireExpiry
+	results    []ouroboros_mock.ConversationEntryOutput
+	nextResult int
+	acquired   bool
+	// point is the acquired point, or nil if one of the tips was acquired
+	point      *common.Point
+	acquiredAt time.Time
+	queries    int
+}
+
+func (s *expiringState) respond(
+	msg protocol.Message,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	switch msg := msg.(type) {
+	case *localstatequery.MsgAcquire:
+		return s.acquire(&msg.Point), nil
+	case *localstatequery.MsgReAcquire:
+		return s.acquire(&msg.Point), nil
+	case *localstatequery.MsgAcquireVolatileTip,
+		*localstatequery.MsgAcquireImmutableTip,
+		*localstatequery.MsgReAcquireVolatileTip,
+		*localstatequery.MsgReAcquireImmutableTip:
+		return s.acquire(nil), nil
+	case *msgQuery:
+		if !s.acquired {
+			return nil, errors.New("query received without an acquired state")
+		}
+		if s.expired() {
+			return nil, ErrStateExpired
+		}
+		if s.nextResult >= len(s.results) {
+			return nil, fmt.Errorf("no result for query %d", s.nextResult+1)
+		}
+		result := s.results[s.nextResult]
+		s.nextResult++
+		s.queries++
+		return []ouroboros_mock.ConversationEntry{result}, nil
+	case *localstatequery.MsgRelease:
+		s.acquired = false
+		return nil, nil
+	case *localstatequery.MsgDone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected message type: %T", msg)
+	}
+}
+
+// acquire handles a request to acquire the provided point, or one of the tips if the point is nil. Reacquiring the
+// same point keeps the age and query count of the state
+func (s *expiringState) acquire(
+	point *common.Point,
+) []ouroboros_mock.ConversationEntry {
+	samePoint := point != nil && s.point != nil && point.Slot == s.point.Slot &&
+		bytes.Equal(point.Hash, s.point.Hash)
+	if samePoint && s.expired() {
+		s.acquired = false
+		return []ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryOutput{
+				ProtocolId: localstatequery.ProtocolId,
+				IsResponse: true,
+				Messages: []protocol.Message{
+					localstatequery.NewMsgFailure(
+						localstatequery.AcquireFailurePointTooOld,
+					),
+				},
+			},
+		}
+	}
+	if !samePoint {
+		s.point = point
+		s.acquiredAt = time.Now()
+		s.queries = 0
+	}
+	s.acquired = true
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryLocalStateQueryAcquiredResponse,
+	}
+}
+
+func (s *expiringState) expired() bool {
+	if s.expiry.MaxQueries > 0 && s.queries >= s.expiry.MaxQueries {
+		return true
+	}
+	if s.expiry.Lifetime > 0 && time.Since(s.acquiredAt) >= s.expiry.Lifetime {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"go.uber.org/goleak"
+)
+
+// runExpiringState starts a NtC client against a mock connection serving an expiring state, calls the provided
+// function with the client connection, and returns the error from the mock connection, if any
+func runExpiringState(
+	t *testing.T,
+	expiry lsq.AcquireExpiry,
+	resultCount int,
+	queryFunc func(*ouroboros.Connection),
+) error {
+	t.Helper()
+	var results []ouroboros_mock.ConversationEntryOutput
+	for i := 0; i < resultCount; i++ {
+		result, err := lsq.NewSystemStartResult(time.Now())
+		if err != nil {
+			t.Fatalf("unexpected error building result: %s", err)
+		}
+		results = append(results, result)
+	}
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			lsq.NewConversationEntryExpiringState(expiry, results...),
+		},
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	queryFunc(oConn)
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+	select {
+	case err := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatalf("mock connection did not shutdown within timeout")
+	}
+	return nil
+}
+
+func TestExpiringStateReAcquire(t *testing.T) {
+	testDefs := []struct {
+		name   string
+		expiry lsq.AcquireExpiry
+	}{
+		{
+			name: "MaxQueries",
+			expiry: lsq.AcquireExpiry{
+				MaxQueries: 1,
+			},
+		},
+		{
+			name: "Lifetime",
+			expiry: lsq.AcquireExpiry{
+				Lifetime: 200 * time.Millisecond,
+			},
+		},
+	}
+	point := common.NewPoint(1234, []byte{0xab, 0xcd})
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			err := runExpiringState(t, testDef.expiry, 1, func(oConn *ouroboros.Connection) {
+				client := oConn.LocalStateQuery().Client
+				if err := client.Acquire(&point); err != nil {
+					t.Fatalf("unexpected error acquiring point: %s", err)
+				}
+				if _, err := client.GetSystemStart(); err != nil {
+					t.Fatalf("unexpected error querying system start: %s", err)
+				}
+				// Reacquiring the point succeeds until the state expires
+				if testDef.expiry.Lifetime > 0 {
+					if err := client.Acquire(&point); err != nil {
+						t.Fatalf("unexpected error reacquiring point: %s", err)
+					}
+					time.Sleep(testDef.expiry.Lifetime)
+				}
+				if err := client.Acquire(&point); !errors.Is(err, localstatequery.ErrAcquireFailurePointTooOld) {
+					t.Fatalf("did not get expected error reacquiring expired point: got %v", err)
+				}
+			})
+			if err != nil {
+				t.Fatalf("unexpected mock connection error: %s", err)
+			}
+		})
+	}
+}
+
+func TestExpiringStateQuery(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	err := runExpiringState(
+		t,
+		lsq.AcquireExpiry{
+			MaxQueries: 1,
+		},
+		2,
+		func(oConn *ouroboros.Connection) {
+			client := oConn.LocalStateQuery().Client
+			if err := client.AcquireVolatileTip(); err != nil {
+				t.Fatalf("unexpected error acquiring volatile tip: %s", err)
+			}
+			if _, err := client.GetSystemStart(); err != nil {
+				t.Fatalf("unexpected error querying system start: %s", err)
+			}
+			// Acquiring the tip again provides a new state
+			if err := client.AcquireVolatileTip(); err != nil {
+				t.Fatalf("unexpected error reacquiring volatile tip: %s", err)
+			}
+			if _, err := client.GetSystemStart(); err != nil {
+				t.Fatalf("unexpected error querying system start after reacquiring: %s", err)
+			}
+			if _, err := client.GetSystemStart(); err == nil {
+				t.Fatalf("did not get expected error querying expired state")
+			}
+		},
+	)
+	if !errors.Is(err, lsq.ErrStateExpired) {
+		t.Fatalf("did not get expected mock connection error: got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/byron"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// byronQueryTypeUpdateInterfaceState is the only query supported by the Byron ledger
+const byronQueryTypeUpdateInterfaceState = 0
+
+// ByronUpdateInterfaceState is the Byron update interface state, with the adopted protocol version and
+// parameters. There are never any pending update proposals, votes, or endorsements
+type ByronUpdateInterfaceState struct {
+	AdoptedProtocolVersion    byron.ByronBlockVersion
+	AdoptedProtocolParameters byron.ByronGenesisBlockVersionData
+}
+
+func (s ByronUpdateInterfaceState) MarshalCBOR() ([]byte, error) {
+	params := s.AdoptedProtocolParameters
+	// The fee policy is a linear policy wrapped in a CBOR-in-CBOR tag
+	txFeePolicyCbor, err := cbor.Encode(
+		[]any{
+			params.TxFeePolicy.Summand,
+			params.TxFeePolicy.Multiplier,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	tmpData := []any{
+		s.AdoptedProtocolVersion,
+		[]any{
+			params.ScriptVersion,
+			params.SlotDuration,
+			params.MaxBlockSize,
+			params.MaxHeaderSize,
+			params.MaxTxSize,
+			params.MaxProposalSize,
+			params.MpcThd,
+			params.HeavyDelThd,
+			params.UpdateVoteThd,
+			params.UpdateProposalThd,
+			params.UpdateImplicit,
+			[]any{
+				params.SoftforkRule.InitThd,
+				params.SoftforkRule.MinThd,
+				params.SoftforkRule.ThdDecrement,
+			},
+			[]any{
+				0,
+				cbor.Tag{
+					Number:  cbor.CborTagCbor,
+					Content: txFeePolicyCbor,
+				},
+			},
+			params.UnlockStakeEpoch,
+		},
+		// Candidate protocol updates
+		[]any{},
+		// Application versions
+		map[any]any{},
+		// Registered protocol and software update proposals
+		map[any]any{},
+		map[any]any{},
+		// Confirmed proposals
+		map[any]any{},
+		// Proposal votes
+		map[any]any{},
+		// Registered endorsements
+		[]any{},
+		// Proposal registration slots
+		map[any]any{},
+	}
+	return cbor.Encode(tmpData)
+}
+
+// NewByronUpdateInterfaceStateQuery returns a conversation entry that matches a query for the Byron update
+// interface state
+func NewByronUpdateInterfaceStateQuery() (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			ledger.EraIdByron,
+			byronQueryTypeUpdateInterfaceState,
+		),
+	)
+}
+
+// NewByronUpdateInterfaceStateResult returns a conversation entry for a Byron update interface state query
+// result containing the provided state
+func NewByronUpdateInterfaceStateResult(
+	state ByronUpdateInterfaceState,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(
+		[]any{state},
+	)
+}
+
+// NewChainPointQuery returns a conversation entry that matches a query for the chain point. The Byron ledger
+// has no query for the last block slot, so clients use this era-independent query instead
+func NewChainPointQuery() (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildQuery(localstatequery.QueryTypeChainPoint),
+	)
+}
+
+// NewChainPointResult returns a conversation entry for a chain point query result with the provided point
+func NewChainPointResult(
+	point common.Point,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(&point)
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/byron"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"go.uber.org/goleak"
+)
+
+func TestByronUpdateInterfaceStateResult(t *testing.T) {
+	state := lsq.ByronUpdateInterfaceState{
+		AdoptedProtocolVersion: byron.ByronBlockVersion{Major: 1},
+		AdoptedProtocolParameters: byron.ByronGenesisBlockVersionData{
+			MaxBlockSize:     2000000,
+			MaxTxSize:        4096,
+			SlotDuration:     20000,
+			UnlockStakeEpoch: 18446744073709551615,
+			TxFeePolicy: byron.ByronGenesisBlockVersionDataTxFeePolicy{
+				Multiplier: 43946000000,
+				Summand:    155381000000000,
+			},
+		},
+	}
+	entry, err := lsq.NewByronUpdateInterfaceStateResult(state)
+	if err != nil {
+		t.Fatalf("unexpected error building result: %s", err)
+	}
+	var result struct {
+		cbor.StructAsArray
+		State []cbor.RawMessage
+	}
+	decodeResult(t, entry, &result)
+	if len(result.State) != 10 {
+		t.Fatalf("did not get expected number of state fields: got %d, expected 10", len(result.State))
+	}
+	var version byron.ByronBlockVersion
+	if _, err := cbor.Decode(result.State[0], &version); err != nil {
+		t.Fatalf("unexpected error decoding protocol version: %s", err)
+	}
+	if version.Major != 1 || version.Minor != 0 {
+		t.Fatalf("did not get expected protocol version: %#v", version)
+	}
+	var params []any
+	if _, err := cbor.Decode(result.State[1], &params); err != nil {
+		t.Fatalf("unexpected error decoding protocol params: %s", err)
+	}
+	if len(params) != 14 {
+		t.Fatalf("did not get expected number of protocol params: got %d, expected 14", len(params))
+	}
+	if params[2] != uint64(2000000) || params[13] != uint64(18446744073709551615) {
+		t.Fatalf("did not get expected protocol params: %v", params)
+	}
+}
+
+func TestChainPoint(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	point := common.NewPoint(4492799, bytes.Repeat([]byte{0xab}, 32))
+	conversation := newTestConversation(t)
+	conversation.add(lsq.NewChainPointQuery())
+	conversation.add(lsq.NewChainPointResult(point))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetChainPoint()
+		if err != nil {
+			t.Fatalf("unexpected error querying chain point: %s", err)
+		}
+		if result.Slot != point.Slot || !bytes.Equal(result.Hash, point.Hash) {
+			t.Fatalf("did not get expected chain point: got %d.%x, expected %d.%x", result.Slot, result.Hash, point.Slot, point.Hash)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"math/big"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// EraBound is the start or end of an era, expressed as the time since the system start along with the slot and
+// epoch
+type EraBound struct {
+	Time  time.Duration
+	Slot  uint64
+	Epoch uint64
+}
+
+// EraSummary describes the bounds and parameters of an era
+type EraSummary struct {
+	Start EraBound
+	// End is the end of the era, or nil if the era is unbounded
+	End         *EraBound
+	EpochLength uint64
+	SlotLength  time.Duration
+	SafeZone    uint64
+}
+
+// MainnetEraHistory is an era history with the same shape as mainnet, with Conway as the current era
+var MainnetEraHistory = []EraSummary{
+	// Byron
+	{
+		Start:       EraBound{},
+		End:         &EraBound{Time: 89856000 * time.Second, Slot: 4492800, Epoch: 208},
+		EpochLength: 21600,
+		SlotLength:  20 * time.Second,
+		SafeZone:    4320,
+	},
+	// Shelley
+	{
+		Start:       EraBound{Time: 89856000 * time.Second, Slot: 4492800, Epoch: 208},
+		End:         &EraBound{Time: 101952000 * time.Second, Slot: 16588800, Epoch: 236},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+	// Allegra
+	{
+		Start:       EraBound{Time: 101952000 * time.Second, Slot: 16588800, Epoch: 236},
+		End:         &EraBound{Time: 108432000 * time.Second, Slot: 23068800, Epoch: 251},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+	// Mary
+	{
+		Start:       EraBound{Time: 108432000 * time.Second, Slot: 23068800, Epoch: 251},
+		End:         &EraBound{Time: 125280000 * time.Second, Slot: 39916800, Epoch: 290},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+	// Alonzo
+	{
+		Start:       EraBound{Time: 125280000 * time.Second, Slot: 39916800, Epoch: 290},
+		End:         &EraBound{Time: 157680000 * time.Second, Slot: 72316800, Epoch: 365},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+	// Babbage
+	{
+		Start:       EraBound{Time: 157680000 * time.Second, Slot: 72316800, Epoch: 365},
+		End:         &EraBound{Time: 219024000 * time.Second, Slot: 133660800, Epoch: 507},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+	// Conway
+	{
+		Start:       EraBound{Time: 219024000 * time.Second, Slot: 133660800, Epoch: 507},
+		EpochLength: 432000,
+		SlotLength:  time.Second,
+		SafeZone:    129600,
+	},
+}
+
+// NewEraHistoryQuery returns a conversation entry that matches a query for the era history
+func NewEraHistoryQuery() (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildHardForkQuery(localstatequery.QueryTypeHardForkEraHistory),
+	)
+}
+
+// NewEraHistoryResult returns a conversation entry for an era history query result containing the provided era
+// summaries
+func NewEraHistoryResult(
+	summaries []EraSummary,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	result := make([]any, 0, len(summaries))
+	for _, summary := range summaries {
+		var end any
+		if summary.End != nil {
+			end = encodeEraBound(*summary.End)
+		}
+		result = append(
+			result,
+			[]any{
+				encodeEraBound(summary.Start),
+				end,
+				[]any{
+					summary.EpochLength,
+					summary.SlotLength.Milliseconds(),
+					// Standard safe zone
+					[]any{0, summary.SafeZone, []any{0}},
+				},
+			},
+		)
+	}
+	return NewConversationEntryResult(result)
+}
+
+// encodeEraBound returns the list representation of an era bound used on the wire, with the time in picoseconds
+func encodeEraBound(bound EraBound) []any {
+	picoseconds := new(big.Int).Mul(
+		big.NewInt(bound.Time.Nanoseconds()),
+		big.NewInt(1000),
+	)
+	return []any{
+		picoseconds,
+		bound.Slot,
+		bound.Epoch,
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"go.uber.org/goleak"
+)
+
+func TestEraHistory(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	conversation := newTestConversation(t)
+	conversation.add(lsq.NewEraHistoryQuery())
+	conversation.add(lsq.NewEraHistoryResult(lsq.MainnetEraHistory))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetEraHistory()
+		if err != nil {
+			t.Fatalf("unexpected error querying era history: %s", err)
+		}
+		if len(result) != len(lsq.MainnetEraHistory) {
+			t.Fatalf("did not get expected number of eras: got %d, expected %d", len(result), len(lsq.MainnetEraHistory))
+		}
+		for idx, era := range result {
+			expected := lsq.MainnetEraHistory[idx]
+			if uint64(era.Begin.SlotNo) != expected.Start.Slot || uint64(era.Begin.EpochNo) != expected.Start.Epoch {
+				t.Fatalf("era %d did not have expected start: got slot %d epoch %d", idx, era.Begin.SlotNo, era.Begin.EpochNo)
+			}
+			if expected.End != nil && uint64(era.End.SlotNo) != expected.End.Slot {
+				t.Fatalf("era %d did not have expected end slot: got %d, expected %d", idx, era.End.SlotNo, expected.End.Slot)
+			}
+			if uint64(era.Params.EpochLength) != expected.EpochLength {
+				t.Fatalf("era %d did not have expected epoch length: got %d, expected %d", idx, era.Params.EpochLength, expected.EpochLength)
+			}
+			if int64(era.Params.SlotLength) != expected.SlotLength.Milliseconds() {
+				t.Fatalf("era %d did not have expected slot length: got %d", idx, era.Params.SlotLength)
+			}
+		}
+	})
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"math/big"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+// Conway-era Shelley query types
+const (
+	QueryTypeShelleyConstitution          = 23
+	QueryTypeShelleyGovState              = 24
+	QueryTypeShelleyDRepState             = 25
+	QueryTypeShelleyCommitteeMembersState = 27
+)
+
+// Committee member statuses
+const (
+	CommitteeMemberStatusActive       = 0
+	CommitteeMemberStatusExpired      = 1
+	CommitteeMemberStatusUnrecognized = 2
+)
+
+// Committee member hot key authorization statuses
+const (
+	hotCredAuthStatusAuthorized    = 0
+	hotCredAuthStatusNotAuthorized = 1
+	hotCredAuthStatusResigned      = 2
+)
+
+// nextEpochChangeNoChangeExpected indicates that no change to a committee member is expected in the next epoch
+const nextEpochChangeNoChangeExpected = 2
+
+// DRepState holds the registration state for a DRep
+type DRepState struct {
+	Credential common.StakeCredential
+	Expiry     uint64
+	Anchor     *common.GovAnchor
+	Deposit    uint64
+}
+
+// CommitteeMemberState holds the state of a constitutional committee member
+type CommitteeMemberState struct {
+	ColdCredential common.StakeCredential
+	// HotCredential is the authorized hot credential, or nil if the member hasn't authorized a hot credential
+	HotCredential *common.StakeCredential
+	// Resigned indicates that the member has resigned. This is only used when HotCredential is nil
+	Resigned   bool
+	Status     uint
+	Expiration *uint64
+}
+
+// NewConstitutionQuery returns a conversation entry that matches a query for the constitution in the specified
+// era
+func NewConstitutionQuery(era uint) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(era, QueryTypeShelleyConstitution),
+	)
+}
+
+// NewConstitutionResult returns a conversation entry for a constitution query result with the provided anchor and
+// optional guardrails script hash
+func NewConstitutionResult(
+	anchor common.GovAnchor,
+	scriptHash []byte,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(
+		[]any{
+			[]any{
+				anchor,
+				scriptHash,
+			},
+		},
+	)
+}
+
+// NewGovStateQuery returns a conversation entry that matches a query for the governance state in the specified
+// era
+func NewGovStateQuery(era uint) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(era, QueryTypeShelleyGovState),
+	)
+}
+
+// NewGovStateResult returns a conversation entry for a governance state query result. The governance state is
+// encoded to CBOR unless it's already a cbor.RawMessage, such as a result captured from a real node
+func NewGovStateResult(
+	govState any,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(
+		[]any{govState},
+	)
+}
+
+// NewDRepStateQuery returns a conversation entry that matches a query for the state of the provided DRep
+// credentials in the specified era
+func NewDRepStateQuery(
+	era uint,
+	creds []common.StakeCredential,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			QueryTypeShelleyDRepState,
+			credentialSet(creds),
+		),
+	)
+}
+
+// NewDRepStateResult returns a conversation entry for a DRep state query result containing the provided DReps
+func NewDRepStateResult(
+	dreps []DRepState,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	result := map[*common.StakeCredential]any{}
+	for idx := range dreps {
+		drep := &dreps[idx]
+		result[&drep.Credential] = []any{
+			drep.Expiry,
+			encodeMaybe(drep.Anchor),
+			drep.Deposit,
+		}
+	}
+	return NewConversationEntryResult(
+		[]any{result},
+	)
+}
+
+// NewCommitteeMembersStateQuery returns a conversation entry that matches a query for the state of the
+// constitutional committee in the specified era, filtered by the provided cold credentials, hot credentials, and
+// member statuses
+func NewCommitteeMembersStateQuery(
+	era uint,
+	coldCreds []common.StakeCredential,
+	hotCreds []common.StakeCredential,
+	statuses []uint,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	if statuses == nil {
+		statuses = []uint{}
+	}
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			QueryTypeShelleyCommitteeMembersState,
+			credentialSet(coldCreds),
+			credentialSet(hotCreds),
+			cbor.Tag{
+				Number:  cbor.CborTagSet,
+				Content: statuses,
+			},
+		),
+	)
+}
+
+// NewCommitteeMembersStateResult returns a conversation entry for a committee members state query result
+// containing the provided members, along with the optional quorum threshold and the current epoch
+func NewCommitteeMembersStateResult(
+	members []CommitteeMemberState,
+	threshold *big.Rat,
+	epoch uint64,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	memberStates := map[*common.StakeCredential]any{}
+	for idx := range members {
+		member := &members[idx]
+		var hotCredAuthStatus []any
+		switch {
+		case member.HotCredential != nil:
+			hotCredAuthStatus = []any{
+				hotCredAuthStatusAuthorized,
+				member.HotCredential,
+			}
+		case member.Resigned:
+			hotCredAuthStatus = []any{hotCredAuthStatusResigned, []any{}}
+		default:
+			hotCredAuthStatus = []any{hotCredAuthStatusNotAuthorized, []any{}}
+		}
+		memberStates[&member.ColdCredential] = []any{
+			hotCredAuthStatus,
+			member.Status,
+			encodeMaybe(member.Expiration),
+			[]any{nextEpochChangeNoChangeExpected},
+		}
+	}
+	var tmpThreshold *cbor.Rat
+	if threshold != nil {
+		tmpThreshold = &cbor.Rat{Rat: threshold}
+	}
+	return NewConversationEntryResult(
+		[]any{
+			[]any{
+				memberStates,
+				encodeMaybe(tmpThreshold),
+				epoch,
+			},
+		},
+	)
+}
+
+// encodeMaybe returns the list representation of an optional value used in ledger state, which is an empty list
+// when the value isn't present
+func encodeMaybe[T any](val *T) []any {
+	if val == nil {
+		return []any{}
+	}
+	return []any{val}
+}
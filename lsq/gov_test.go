@@ -0,0 +1,157 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestConstitutionResult(t *testing.T) {
+	anchor := common.GovAnchor{
+		Url:      "https://example.com/constitution",
+		DataHash: [32]byte{0x01},
+	}
+	scriptHash := bytes.Repeat([]byte{0xcc}, 28)
+	entry, err := lsq.NewConstitutionResult(anchor, scriptHash)
+	if err != nil {
+		t.Fatalf("unexpected error building result: %s", err)
+	}
+	var result struct {
+		cbor.StructAsArray
+		Constitution struct {
+			cbor.StructAsArray
+			Anchor     common.GovAnchor
+			ScriptHash []byte
+		}
+	}
+	decodeResult(t, entry, &result)
+	if result.Constitution.Anchor.Url != anchor.Url || result.Constitution.Anchor.DataHash != anchor.DataHash {
+		t.Fatalf("did not get expected anchor: %#v", result.Constitution.Anchor)
+	}
+	if !bytes.Equal(result.Constitution.ScriptHash, scriptHash) {
+		t.Fatalf("did not get expected script hash: got %x, expected %x", result.Constitution.ScriptHash, scriptHash)
+	}
+}
+
+func TestDRepStateResult(t *testing.T) {
+	anchor := common.GovAnchor{
+		Url: "https://example.com/drep.json",
+	}
+	dreps := []lsq.DRepState{
+		{
+			Credential: common.StakeCredential{
+				CredType:   common.StakeCredentialTypeAddrKeyHash,
+				Credential: bytes.Repeat([]byte{0xaa}, 28),
+			},
+			Expiry:  500,
+			Anchor:  &anchor,
+			Deposit: 500_000_000,
+		},
+	}
+	entry, err := lsq.NewDRepStateResult(dreps)
+	if err != nil {
+		t.Fatalf("unexpected error building result: %s", err)
+	}
+	var result struct {
+		cbor.StructAsArray
+		DReps map[*common.StakeCredential]struct {
+			cbor.StructAsArray
+			Expiry  uint64
+			Anchor  []common.GovAnchor
+			Deposit uint64
+		}
+	}
+	decodeResult(t, entry, &result)
+	if len(result.DReps) != 1 {
+		t.Fatalf("did not get expected number of DReps: got %d, expected %d", len(result.DReps), 1)
+	}
+	for cred, drep := range result.DReps {
+		if !bytes.Equal(cred.Credential, dreps[0].Credential.Credential) {
+			t.Fatalf("did not get expected DRep credential: got %x", cred.Credential)
+		}
+		if drep.Expiry != dreps[0].Expiry || drep.Deposit != dreps[0].Deposit {
+			t.Fatalf("did not get expected DRep expiry/deposit: got %d/%d", drep.Expiry, drep.Deposit)
+		}
+		if len(drep.Anchor) != 1 || drep.Anchor[0].Url != anchor.Url {
+			t.Fatalf("did not get expected DRep anchor: %#v", drep.Anchor)
+		}
+	}
+}
+
+func TestCommitteeMembersStateResult(t *testing.T) {
+	expiration := uint64(600)
+	members := []lsq.CommitteeMemberState{
+		{
+			ColdCredential: common.StakeCredential{
+				CredType:   common.StakeCredentialTypeAddrKeyHash,
+				Credential: bytes.Repeat([]byte{0xaa}, 28),
+			},
+			HotCredential: &common.StakeCredential{
+				CredType:   common.StakeCredentialTypeAddrKeyHash,
+				Credential: bytes.Repeat([]byte{0xbb}, 28),
+			},
+			Status:     lsq.CommitteeMemberStatusActive,
+			Expiration: &expiration,
+		},
+	}
+	entry, err := lsq.NewCommitteeMembersStateResult(members, big.NewRat(2, 3), 500)
+	if err != nil {
+		t.Fatalf("unexpected error building result: %s", err)
+	}
+	var result struct {
+		cbor.StructAsArray
+		State struct {
+			cbor.StructAsArray
+			Members map[*common.StakeCredential]struct {
+				cbor.StructAsArray
+				HotCredAuthStatus struct {
+					cbor.StructAsArray
+					Type          uint
+					HotCredential common.StakeCredential
+				}
+				Status          uint
+				Expiration      []uint64
+				NextEpochChange []any
+			}
+			Threshold []cbor.Rat
+			Epoch     uint64
+		}
+	}
+	decodeResult(t, entry, &result)
+	if result.State.Epoch != 500 {
+		t.Fatalf("did not get expected epoch: got %d, expected %d", result.State.Epoch, 500)
+	}
+	if len(result.State.Threshold) != 1 || result.State.Threshold[0].Cmp(big.NewRat(2, 3)) != 0 {
+		t.Fatalf("did not get expected threshold: %#v", result.State.Threshold)
+	}
+	if len(result.State.Members) != 1 {
+		t.Fatalf("did not get expected number of members: got %d, expected %d", len(result.State.Members), 1)
+	}
+	for _, member := range result.State.Members {
+		if !bytes.Equal(member.HotCredAuthStatus.HotCredential.Credential, members[0].HotCredential.Credential) {
+			t.Fatalf("did not get expected hot credential: got %x", member.HotCredAuthStatus.HotCredential.Credential)
+		}
+		if len(member.Expiration) != 1 || member.Expiration[0] != expiration {
+			t.Fatalf("did not get expected expiration: %#v", member.Expiration)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"fmt"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// msgQuery is a local-state-query Query message that keeps the query as raw CBOR, which allows comparing
+// the query sent by a client to the expected query without decoding it
+type msgQuery struct {
+	protocol.MessageBase
+	Query cbor.RawMessage
+}
+
+// newMsgFromCbor decodes a local-state-query message from CBOR, keeping the query in Query messages as raw CBOR
+func newMsgFromCbor(msgType uint, data []byte) (protocol.Message, error) {
+	if msgType != localstatequery.MessageTypeQuery {
+		return localstatequery.NewMsgFromCbor(msgType, data)
+	}
+	var ret msgQuery
+	if _, err := cbor.Decode(data, &ret); err != nil {
+		return nil, fmt.Errorf("%s: decode error: %s", localstatequery.ProtocolName, err)
+	}
+	// Store the raw message CBOR
+	ret.SetCbor(data)
+	return &ret, nil
+}
+
+// NewConversationEntryQuery returns a conversation entry that matches a Query message from a client containing
+// the provided query. The query is compared to the client's query by its CBOR encoding
+func NewConversationEntryQuery(
+	query any,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	queryCbor, err := cbor.Encode(query)
+	if err != nil {
+		return ouroboros_mock.ConversationEntryInput{}, err
+	}
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId: localstatequery.ProtocolId,
+		Message: &msgQuery{
+			MessageBase: protocol.MessageBase{
+				MessageType: localstatequery.MessageTypeQuery,
+			},
+			Query: cbor.RawMessage(queryCbor),
+		},
+		MsgFromCborFunc: newMsgFromCbor,
+	}, nil
+}
+
+// NewConversationEntryResult returns a conversation entry for a Result message containing the provided result
+func NewConversationEntryResult(
+	result any,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	resultCbor, err := cbor.Encode(result)
+	if err != nil {
+		return ouroboros_mock.ConversationEntryOutput{}, err
+	}
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: localstatequery.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			localstatequery.NewMsgResult(resultCbor),
+		},
+	}, nil
+}
+
+// NewConversationEntryLazyResult returns a conversation entry for a Result message containing the result returned by
+// the provided function, which is called when the entry is processed
+func NewConversationEntryLazyResult(
+	resultFunc func() (any, error),
+) ouroboros_mock.ConversationEntryOutput {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: localstatequery.ProtocolId,
+		IsResponse: true,
+		MessagesFunc: func() ([]protocol.Message, error) {
+			result, err := resultFunc()
+			if err != nil {
+				return nil, err
+			}
+			resultCbor, err := cbor.Encode(result)
+			if err != nil {
+				return nil, err
+			}
+			return []protocol.Message{
+				localstatequery.NewMsgResult(resultCbor),
+			}, nil
+		},
+	}
+}
+
+// NewCurrentEraQuery returns a conversation entry that matches a query for the current era
+func NewCurrentEraQuery() (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildHardForkQuery(localstatequery.QueryTypeHardForkCurrentEra),
+	)
+}
+
+// NewCurrentEraResult returns a conversation entry for a current era query result with the provided era ID
+func NewCurrentEraResult(
+	era uint,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(era)
+}
+
+func buildQuery(queryType int, params ...any) []any {
+	ret := []any{queryType}
+	if len(params) > 0 {
+		ret = append(ret, params...)
+	}
+	return ret
+}
+
+func buildHardForkQuery(queryType int, params ...any) []any {
+	return buildQuery(
+		localstatequery.QueryTypeBlock,
+		buildQuery(
+			localstatequery.QueryTypeHardFork,
+			buildQuery(
+				queryType,
+				params...,
+			),
+		),
+	)
+}
+
+func buildShelleyQuery(era uint, queryType int, params ...any) []any {
+	return buildQuery(
+		localstatequery.QueryTypeBlock,
+		buildQuery(
+			localstatequery.QueryTypeShelley,
+			[]any{
+				era,
+				buildQuery(
+					queryType,
+					params...,
+				),
+			},
+		),
+	)
+}
+
+// credentialSet returns the provided credentials wrapped in a CBOR set tag
+func credentialSet(creds []common.StakeCredential) cbor.Tag {
+	if creds == nil {
+		creds = []common.StakeCredential{}
+	}
+	return cbor.Tag{
+		Number:  cbor.CborTagSet,
+		Content: creds,
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"go.uber.org/goleak"
+)
+
+const testEra = ledger.EraIdConway
+
+const testRewardAddress = "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"
+
+// testConversation collects conversation entries from the builder functions, failing the test on any error
+type testConversation struct {
+	t       *testing.T
+	entries []ouroboros_mock.ConversationEntry
+}
+
+func newTestConversation(t *testing.T) *testConversation {
+	return &testConversation{t: t}
+}
+
+func (c *testConversation) add(entry ouroboros_mock.ConversationEntry, err error) {
+	c.t.Helper()
+	if err != nil {
+		c.t.Fatalf("unexpected error building conversation entry: %s", err)
+	}
+	c.entries = append(c.entries, entry)
+}
+
+// addCurrentEra adds the current era query that the client makes before each era-specific query
+func (c *testConversation) addCurrentEra() {
+	c.t.Helper()
+	c.add(lsq.NewCurrentEraQuery())
+	c.add(lsq.NewCurrentEraResult(testEra))
+}
+
+// decodeResult decodes the result from a result conversation entry
+func decodeResult(
+	t *testing.T,
+	entry ouroboros_mock.ConversationEntryOutput,
+	dest any,
+) {
+	t.Helper()
+	msg, ok := entry.Messages[0].(*localstatequery.MsgResult)
+	if !ok {
+		t.Fatalf("did not get expected message type: %T", entry.Messages[0])
+	}
+	if _, err := cbor.Decode(msg.Result, dest); err != nil {
+		t.Fatalf("unexpected error decoding result: %s", err)
+	}
+}
+
+// runQueries starts a NtC client against a mock connection with the provided LSQ conversation entries, which
+// follow the handshake and acquire, and calls the provided function with the client connection
+func runQueries(
+	t *testing.T,
+	entries []ouroboros_mock.ConversationEntry,
+	queryFunc func(*ouroboros.Connection),
+) {
+	conversation := append(
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			ouroboros_mock.ConversationEntryLocalStateQueryAcquireVolatileTipRequest,
+			ouroboros_mock.ConversationEntryLocalStateQueryAcquiredResponse,
+		},
+		entries...,
+	)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	queryFunc(oConn)
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestStakePools(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	poolIds := []common.PoolId{{0x01}, {0x02}}
+	conversation := newTestConversation(t)
+	conversation.addCurrentEra()
+	conversation.add(lsq.NewStakePoolsQuery(testEra))
+	conversation.add(lsq.NewStakePoolsResult(poolIds))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetStakePools()
+		if err != nil {
+			t.Fatalf("unexpected error querying stake pools: %s", err)
+		}
+		if len(result.Results) != len(poolIds) {
+			t.Fatalf("did not get expected number of pools: got %d, expected %d", len(result.Results), len(poolIds))
+		}
+		for idx, poolId := range poolIds {
+			if result.Results[idx] != poolId {
+				t.Fatalf("did not get expected pool ID: got %s, expected %s", result.Results[idx], poolId)
+			}
+		}
+	})
+}
+
+func TestStakePoolParams(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	rewardAddr, err := common.NewAddress(testRewardAddress)
+	if err != nil {
+		t.Fatalf("unexpected error decoding address: %s", err)
+	}
+	port := uint32(3001)
+	ipv4 := net.ParseIP("192.0.2.1")
+	hostname := "relay.example.com"
+	poolId := common.PoolId{0x01}
+	params := lsq.StakePoolParams{
+		Operator:      common.Blake2b224(poolId),
+		VrfKeyHash:    common.Blake2b256{0x02},
+		Pledge:        1_000_000,
+		FixedCost:     340_000_000,
+		Margin:        big.NewRat(1, 100),
+		RewardAccount: rewardAddr,
+		PoolOwners:    []common.Blake2b224{{0x03}},
+		Relays: []common.PoolRelay{
+			{
+				Type: common.PoolRelayTypeSingleHostAddress,
+				Port: &port,
+				Ipv4: &ipv4,
+			},
+			{
+				Type:     common.PoolRelayTypeSingleHostName,
+				Port:     &port,
+				Hostname: &hostname,
+			},
+		},
+		PoolMetadata: &common.PoolMetadata{
+			Url: "https://example.com/pool.json",
+		},
+	}
+	conversation := newTestConversation(t)
+	conversation.addCurrentEra()
+	conversation.add(lsq.NewStakePoolParamsQuery(testEra, []common.PoolId{poolId}))
+	conversation.add(lsq.NewStakePoolParamsResult(map[common.PoolId]lsq.StakePoolParams{poolId: params}))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetStakePoolParams([]ledger.PoolId{poolId})
+		if err != nil {
+			t.Fatalf("unexpected error querying stake pool params: %s", err)
+		}
+		poolParams, ok := result.Results[poolId]
+		if !ok {
+			t.Fatalf("did not find expected pool in result")
+		}
+		if poolParams.Pledge != params.Pledge || poolParams.FixedCost != params.FixedCost {
+			t.Fatalf("did not get expected pledge/cost: got %d/%d", poolParams.Pledge, poolParams.FixedCost)
+		}
+		if poolParams.Margin.Cmp(params.Margin) != 0 {
+			t.Fatalf("did not get expected margin: got %s, expected %s", poolParams.Margin, params.Margin)
+		}
+		if poolParams.RewardAccount.String() != testRewardAddress {
+			t.Fatalf("did not get expected reward account: got %s", poolParams.RewardAccount.String())
+		}
+		if len(poolParams.Relays) != 2 || *poolParams.Relays[1].Hostname != hostname {
+			t.Fatalf("did not get expected relays: %#v", poolParams.Relays)
+		}
+		if poolParams.PoolMetadata == nil || poolParams.PoolMetadata.Url != params.PoolMetadata.Url {
+			t.Fatalf("did not get expected pool metadata: %#v", poolParams.PoolMetadata)
+		}
+	})
+}
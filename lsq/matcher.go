@@ -0,0 +1,127 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"fmt"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// QueryMatcher reports whether the query sent by a client, provided as CBOR, matches
+type QueryMatcher func(queryCbor []byte) bool
+
+// queryWrapper is used to unwrap one level of a nested query
+type queryWrapper struct {
+	cbor.StructAsArray
+	Type     int
+	SubQuery cbor.RawMessage
+}
+
+// shelleyQueryWrapper is used to unwrap the era from a Shelley query
+type shelleyQueryWrapper struct {
+	cbor.StructAsArray
+	Era      uint
+	SubQuery cbor.RawMessage
+}
+
+// NewConversationEntryQueryMatch returns a conversation entry that matches a Query message from a client with a
+// query accepted by the provided matcher. A mismatch reports the query that the client sent
+func NewConversationEntryQueryMatch(
+	matcher QueryMatcher,
+) ouroboros_mock.ConversationEntryInput {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId:      localstatequery.ProtocolId,
+		MsgFromCborFunc: newMsgFromCbor,
+		Matcher: func(msg protocol.Message) bool {
+			queryMsg, ok := msg.(*msgQuery)
+			if !ok {
+				return false
+			}
+			return matcher(queryMsg.Query)
+		},
+	}
+}
+
+// MatchQueryType returns a QueryMatcher that matches any Shelley-era query of the specified type, regardless of
+// the era or query parameters. Only queries wrapped in a Shelley block query are matched, so hard-fork queries
+// need MatchHardForkQueryType and top-level queries such as the system start need MatchTopLevelQueryType
+func MatchQueryType(queryType int) QueryMatcher {
+	return func(queryCbor []byte) bool {
+		subQuery, err := unwrapQuery(queryCbor, localstatequery.QueryTypeShelley)
+		if err != nil {
+			return false
+		}
+		var shelleyQuery shelleyQueryWrapper
+		if _, err := cbor.Decode(subQuery, &shelleyQuery); err != nil {
+			return false
+		}
+		tmpType, err := cbor.DecodeIdFromList(shelleyQuery.SubQuery)
+		if err != nil {
+			return false
+		}
+		return tmpType == queryType
+	}
+}
+
+// MatchHardForkQueryType returns a QueryMatcher that matches any hard-fork query of the specified type
+func MatchHardForkQueryType(queryType int) QueryMatcher {
+	return func(queryCbor []byte) bool {
+		subQuery, err := unwrapQuery(queryCbor, localstatequery.QueryTypeHardFork)
+		if err != nil {
+			return false
+		}
+		tmpType, err := cbor.DecodeIdFromList(subQuery)
+		if err != nil {
+			return false
+		}
+		return tmpType == queryType
+	}
+}
+
+// MatchTopLevelQueryType returns a QueryMatcher that matches any query of the specified top-level type, such as
+// QueryTypeSystemStart, QueryTypeChainBlockNo or QueryTypeChainPoint, regardless of the query parameters
+func MatchTopLevelQueryType(queryType int) QueryMatcher {
+	return func(queryCbor []byte) bool {
+		tmpType, err := cbor.DecodeIdFromList(queryCbor)
+		if err != nil {
+			return false
+		}
+		return tmpType == queryType
+	}
+}
+
+// unwrapQuery returns the inner query from a block query of the specified type
+func unwrapQuery(queryCbor []byte, blockQueryType int) ([]byte, error) {
+	var blockQuery queryWrapper
+	if _, err := cbor.Decode(queryCbor, &blockQuery); err != nil {
+		return nil, err
+	}
+	if blockQuery.Type != localstatequery.QueryTypeBlock {
+		return nil, fmt.Errorf("not a block query: %d", blockQuery.Type)
+	}
+	var subQuery queryWrapper
+	if _, err := cbor.Decode(blockQuery.SubQuery, &subQuery); err != nil {
+		return nil, err
+	}
+	if subQuery.Type != blockQueryType {
+		return nil, fmt.Errorf("unexpected block query type: %d", subQuery.Type)
+	}
+	return subQuery.SubQuery, nil
+}
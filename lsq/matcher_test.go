@@ -0,0 +1,177 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"go.uber.org/goleak"
+)
+
+func TestQueryMatcher(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	conversation := newTestConversation(t)
+	conversation.add(
+		lsq.NewConversationEntryQueryMatch(
+			lsq.MatchHardForkQueryType(localstatequery.QueryTypeHardForkCurrentEra),
+		),
+		nil,
+	)
+	conversation.add(lsq.NewCurrentEraResult(testEra))
+	conversation.add(
+		lsq.NewConversationEntryQueryMatch(
+			lsq.MatchQueryType(localstatequery.QueryTypeShelleyEpochNo),
+		),
+		nil,
+	)
+	conversation.add(lsq.NewConversationEntryResult([]any{500}))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		epochNo, err := oConn.LocalStateQuery().Client.GetEpochNo()
+		if err != nil {
+			t.Fatalf("unexpected error querying epoch: %s", err)
+		}
+		if epochNo != 500 {
+			t.Fatalf("did not get expected epoch: got %d, expected %d", epochNo, 500)
+		}
+	})
+}
+
+func TestMatchQueryType(t *testing.T) {
+	testDefs := []struct {
+		query    []any
+		matcher  lsq.QueryMatcher
+		expected bool
+	}{
+		{
+			// Query parameters are ignored
+			query:    []any{0, []any{0, []any{6, []any{17, cbor.Tag{Number: cbor.CborTagSet, Content: []any{}}}}}},
+			matcher:  lsq.MatchQueryType(localstatequery.QueryTypeShelleyStakePoolParams),
+			expected: true,
+		},
+		{
+			// The era is ignored
+			query:    []any{0, []any{0, []any{1, []any{3}}}},
+			matcher:  lsq.MatchQueryType(localstatequery.QueryTypeShelleyCurrentProtocolParams),
+			expected: true,
+		},
+		{
+			query:    []any{0, []any{0, []any{6, []any{1}}}},
+			matcher:  lsq.MatchQueryType(localstatequery.QueryTypeShelleyCurrentProtocolParams),
+			expected: false,
+		},
+		{
+			// Hard-fork query with the same type number as the Shelley query
+			query:    []any{0, []any{2, []any{1}}},
+			matcher:  lsq.MatchQueryType(localstatequery.QueryTypeShelleyEpochNo),
+			expected: false,
+		},
+		{
+			query:    []any{0, []any{2, []any{1}}},
+			matcher:  lsq.MatchHardForkQueryType(localstatequery.QueryTypeHardForkCurrentEra),
+			expected: true,
+		},
+		{
+			query:    []any{1},
+			matcher:  lsq.MatchHardForkQueryType(localstatequery.QueryTypeHardForkCurrentEra),
+			expected: false,
+		},
+		{
+			query:    []any{1},
+			matcher:  lsq.MatchTopLevelQueryType(localstatequery.QueryTypeSystemStart),
+			expected: true,
+		},
+		{
+			query:    []any{2},
+			matcher:  lsq.MatchTopLevelQueryType(localstatequery.QueryTypeSystemStart),
+			expected: false,
+		},
+		{
+			// Block query with the same type number as the top-level query is ignored
+			query:    []any{0, []any{1, []any{1}}},
+			matcher:  lsq.MatchTopLevelQueryType(localstatequery.QueryTypeSystemStart),
+			expected: false,
+		},
+	}
+	for idx, testDef := range testDefs {
+		queryCbor, err := cbor.Encode(testDef.query)
+		if err != nil {
+			t.Fatalf("unexpected error encoding query: %s", err)
+		}
+		if matched := testDef.matcher(queryCbor); matched != testDef.expected {
+			t.Fatalf("test %d: did not get expected match result: got %v, expected %v", idx, matched, testDef.expected)
+		}
+	}
+}
+
+func TestQueryMatcherMismatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			lsq.NewConversationEntryQueryMatch(
+				lsq.MatchQueryType(localstatequery.QueryTypeShelleyEpochNo),
+			),
+		},
+	)
+	// Send a system start query, which doesn't match
+	msgCbor, err := cbor.Encode(
+		localstatequery.NewMsgQuery([]any{localstatequery.QueryTypeSystemStart}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error encoding query: %s", err)
+	}
+	segment := muxer.NewSegment(localstatequery.ProtocolId, msgCbor, false)
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.BigEndian, segment.SegmentHeader); err != nil {
+		t.Fatalf("unexpected error encoding segment header: %s", err)
+	}
+	buf.Write(segment.Payload)
+	if _, err := mockConn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error writing segment: %s", err)
+	}
+	select {
+	case err := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		var mismatchErr *ouroboros_mock.InputMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("did not receive expected error type: got %T: %s", err, err)
+		}
+		if mismatchErr.Actual == nil {
+			t.Fatalf("did not get decoded actual message")
+		}
+		if !bytes.Equal(mismatchErr.ActualCbor, msgCbor) {
+			t.Fatalf("did not get expected actual CBOR: got %x, expected %x", mismatchErr.ActualCbor, msgCbor)
+		}
+		if !strings.Contains(err.Error(), "not accepted by matcher") {
+			t.Fatalf("did not get expected error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("did not receive conversation error within timeout")
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"fmt"
+	"math/big"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// StakePoolParams holds the registered parameters for a stake pool
+type StakePoolParams struct {
+	Operator      common.Blake2b224
+	VrfKeyHash    common.Blake2b256
+	Pledge        uint
+	FixedCost     uint
+	Margin        *big.Rat
+	RewardAccount common.Address
+	PoolOwners    []common.Blake2b224
+	Relays        []common.PoolRelay
+	PoolMetadata  *common.PoolMetadata
+}
+
+func (p StakePoolParams) MarshalCBOR() ([]byte, error) {
+	poolOwners := p.PoolOwners
+	if poolOwners == nil {
+		poolOwners = []common.Blake2b224{}
+	}
+	relays := make([]any, 0, len(p.Relays))
+	for _, relay := range p.Relays {
+		tmpRelay, err := encodePoolRelay(relay)
+		if err != nil {
+			return nil, err
+		}
+		relays = append(relays, tmpRelay)
+	}
+	tmpData := []any{
+		p.Operator,
+		p.VrfKeyHash,
+		p.Pledge,
+		p.FixedCost,
+		encodeRat(p.Margin),
+		&p.RewardAccount,
+		poolOwners,
+		relays,
+		p.PoolMetadata,
+	}
+	return cbor.Encode(tmpData)
+}
+
+// encodePoolRelay returns the list representation of a pool relay used on the wire
+func encodePoolRelay(relay common.PoolRelay) ([]any, error) {
+	switch relay.Type {
+	case common.PoolRelayTypeSingleHostAddress:
+		var ipv4, ipv6 []byte
+		if relay.Ipv4 != nil {
+			ipv4 = relay.Ipv4.To4()
+		}
+		if relay.Ipv6 != nil {
+			ipv6 = relay.Ipv6.To16()
+		}
+		return []any{relay.Type, relay.Port, ipv4, ipv6}, nil
+	case common.PoolRelayTypeSingleHostName:
+		return []any{relay.Type, relay.Port, relay.Hostname}, nil
+	case common.PoolRelayTypeMultiHostName:
+		return []any{relay.Type, relay.Hostname}, nil
+	default:
+		return nil, fmt.Errorf("invalid relay type: %d", relay.Type)
+	}
+}
+
+// NewStakePoolsQuery returns a conversation entry that matches a query for the registered stake pools in the
+// specified era
+func NewStakePoolsQuery(era uint) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(era, localstatequery.QueryTypeShelleyStakePools),
+	)
+}
+
+// NewStakePoolsResult returns a conversation entry for a stake pools query result containing the provided pool IDs
+func NewStakePoolsResult(
+	poolIds []common.PoolId,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	if poolIds == nil {
+		poolIds = []common.PoolId{}
+	}
+	return NewConversationEntryResult(
+		localstatequery.StakePoolsResult{
+			Results: poolIds,
+		},
+	)
+}
+
+// NewStakePoolParamsQuery returns a conversation entry that matches a query for the parameters of the provided
+// stake pools in the specified era
+func NewStakePoolParamsQuery(
+	era uint,
+	poolIds []common.PoolId,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			localstatequery.QueryTypeShelleyStakePoolParams,
+			cbor.Tag{
+				Number:  cbor.CborTagSet,
+				Content: poolIds,
+			},
+		),
+	)
+}
+
+// NewStakePoolParamsResult returns a conversation entry for a stake pool params query result containing the
+// provided pool params keyed by pool ID
+func NewStakePoolParamsResult(
+	params map[common.PoolId]StakePoolParams,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	if params == nil {
+		params = map[common.PoolId]StakePoolParams{}
+	}
+	return NewConversationEntryResult(
+		[]any{params},
+	)
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"math/big"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// ProtocolParamsResult holds the Conway-era protocol parameters returned for a current protocol params query
+type ProtocolParamsResult struct {
+	MinFeeA                    uint
+	MinFeeB                    uint
+	MaxBlockBodySize           uint
+	MaxTxSize                  uint
+	MaxBlockHeaderSize         uint
+	KeyDeposit                 uint
+	PoolDeposit                uint
+	MaxEpoch                   uint
+	NOpt                       uint
+	A0                         *big.Rat
+	Rho                        *big.Rat
+	Tau                        *big.Rat
+	ProtocolVersion            common.ProtocolParametersProtocolVersion
+	MinPoolCost                uint64
+	AdaPerUtxoByte             uint64
+	CostModels                 map[uint][]int64
+	ExecutionCosts             ExUnitPrices
+	MaxTxExUnits               common.ExUnit
+	MaxBlockExUnits            common.ExUnit
+	MaxValueSize               uint
+	CollateralPercentage       uint
+	MaxCollateralInputs        uint
+	PoolVotingThresholds       PoolVotingThresholds
+	DRepVotingThresholds       DRepVotingThresholds
+	MinCommitteeSize           uint
+	CommitteeTermLimit         uint64
+	GovActionValidityPeriod    uint64
+	GovActionDeposit           uint64
+	DRepDeposit                uint64
+	DRepInactivityPeriod       uint64
+	MinFeeRefScriptCostPerByte *big.Rat
+}
+
+// ExUnitPrices holds the prices of the memory and CPU steps used by Plutus scripts
+type ExUnitPrices struct {
+	MemPrice  *big.Rat
+	StepPrice *big.Rat
+}
+
+// PoolVotingThresholds holds the stake pool voting thresholds for governance actions
+type PoolVotingThresholds struct {
+	MotionNoConfidence    *big.Rat
+	CommitteeNormal       *big.Rat
+	CommitteeNoConfidence *big.Rat
+	HardForkInitiation    *big.Rat
+	PpSecurityGroup       *big.Rat
+}
+
+// DRepVotingThresholds holds the DRep voting thresholds for governance actions
+type DRepVotingThresholds struct {
+	MotionNoConfidence    *big.Rat
+	CommitteeNormal       *big.Rat
+	CommitteeNoConfidence *big.Rat
+	UpdateToConstitution  *big.Rat
+	HardForkInitiation    *big.Rat
+	PpNetworkGroup        *big.Rat
+	PpEconomicGroup       *big.Rat
+	PpTechnicalGroup      *big.Rat
+	PpGovGroup            *big.Rat
+	TreasuryWithdrawal    *big.Rat
+}
+
+func (p ProtocolParamsResult) MarshalCBOR() ([]byte, error) {
+	costModels := p.CostModels
+	if costModels == nil {
+		costModels = map[uint][]int64{}
+	}
+	tmpData := []any{
+		p.MinFeeA,
+		p.MinFeeB,
+		p.MaxBlockBodySize,
+		p.MaxTxSize,
+		p.MaxBlockHeaderSize,
+		p.KeyDeposit,
+		p.PoolDeposit,
+		p.MaxEpoch,
+		p.NOpt,
+		encodeRat(p.A0),
+		encodeRat(p.Rho),
+		encodeRat(p.Tau),
+		p.ProtocolVersion,
+		p.MinPoolCost,
+		p.AdaPerUtxoByte,
+		costModels,
+		[]any{
+			encodeRat(p.ExecutionCosts.MemPrice),
+			encodeRat(p.ExecutionCosts.StepPrice),
+		},
+		p.MaxTxExUnits,
+		p.MaxBlockExUnits,
+		p.MaxValueSize,
+		p.CollateralPercentage,
+		p.MaxCollateralInputs,
+		[]any{
+			encodeRat(p.PoolVotingThresholds.MotionNoConfidence),
+			encodeRat(p.PoolVotingThresholds.CommitteeNormal),
+			encodeRat(p.PoolVotingThresholds.CommitteeNoConfidence),
+			encodeRat(p.PoolVotingThresholds.HardForkInitiation),
+			encodeRat(p.PoolVotingThresholds.PpSecurityGroup),
+		},
+		[]any{
+			encodeRat(p.DRepVotingThresholds.MotionNoConfidence),
+			encodeRat(p.DRepVotingThresholds.CommitteeNormal),
+			encodeRat(p.DRepVotingThresholds.CommitteeNoConfidence),
+			encodeRat(p.DRepVotingThresholds.UpdateToConstitution),
+			encodeRat(p.DRepVotingThresholds.HardForkInitiation),
+			encodeRat(p.DRepVotingThresholds.PpNetworkGroup),
+			encodeRat(p.DRepVotingThresholds.PpEconomicGroup),
+			encodeRat(p.DRepVotingThresholds.PpTechnicalGroup),
+			encodeRat(p.DRepVotingThresholds.PpGovGroup),
+			encodeRat(p.DRepVotingThresholds.TreasuryWithdrawal),
+		},
+		p.MinCommitteeSize,
+		p.CommitteeTermLimit,
+		p.GovActionValidityPeriod,
+		p.GovActionDeposit,
+		p.DRepDeposit,
+		p.DRepInactivityPeriod,
+		encodeRat(p.MinFeeRefScriptCostPerByte),
+	}
+	return cbor.Encode(tmpData)
+}
+
+// encodeRat returns the CBOR representation of a rational value, which is zero when the value isn't set
+func encodeRat(val *big.Rat) cbor.Rat {
+	if val == nil {
+		val = new(big.Rat)
+	}
+	return cbor.Rat{Rat: val}
+}
+
+// NewProtocolParamsQuery returns a conversation entry that matches a query for the current protocol params in the
+// specified era
+func NewProtocolParamsQuery(era uint) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(era, localstatequery.QueryTypeShelleyCurrentProtocolParams),
+	)
+}
+
+// NewProtocolParamsResult returns a conversation entry for a current protocol params query result containing the
+// provided Conway-era protocol params
+func NewProtocolParamsResult(
+	params ProtocolParamsResult,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(
+		[]any{params},
+	)
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"go.uber.org/goleak"
+)
+
+func TestProtocolParamsResult(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	params := lsq.ProtocolParamsResult{
+		MinFeeA:          44,
+		MinFeeB:          155381,
+		MaxBlockBodySize: 90112,
+		MaxTxSize:        16384,
+		KeyDeposit:       2_000_000,
+		PoolDeposit:      500_000_000,
+		A0:               big.NewRat(3, 10),
+		ProtocolVersion: common.ProtocolParametersProtocolVersion{
+			Major: 10,
+		},
+		CostModels: map[uint][]int64{
+			0: {100, 200},
+		},
+		ExecutionCosts: lsq.ExUnitPrices{
+			MemPrice:  big.NewRat(577, 10000),
+			StepPrice: big.NewRat(721, 10000000),
+		},
+		MaxTxExUnits: common.ExUnit{Mem: 14_000_000, Steps: 10_000_000_000},
+		PoolVotingThresholds: lsq.PoolVotingThresholds{
+			HardForkInitiation: big.NewRat(51, 100),
+		},
+		DRepVotingThresholds: lsq.DRepVotingThresholds{
+			UpdateToConstitution: big.NewRat(3, 4),
+			TreasuryWithdrawal:   big.NewRat(67, 100),
+		},
+		MinCommitteeSize:           7,
+		CommitteeTermLimit:         146,
+		GovActionValidityPeriod:    6,
+		GovActionDeposit:           100_000_000_000,
+		DRepDeposit:                500_000_000,
+		DRepInactivityPeriod:       20,
+		MinFeeRefScriptCostPerByte: big.NewRat(15, 1),
+	}
+	conversation := newTestConversation(t)
+	conversation.addCurrentEra()
+	conversation.add(lsq.NewProtocolParamsQuery(testEra))
+	conversation.add(lsq.NewProtocolParamsResult(params))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetCurrentProtocolParams()
+		if err != nil {
+			t.Fatalf("unexpected error querying protocol params: %s", err)
+		}
+		pparams, ok := result.(*ledger.ConwayProtocolParameters)
+		if !ok {
+			t.Fatalf("did not get expected protocol params type: %T", result)
+		}
+		if pparams.MinFeeA != params.MinFeeA || pparams.PoolDeposit != params.PoolDeposit {
+			t.Fatalf("did not get expected fee/deposit: got %d/%d", pparams.MinFeeA, pparams.PoolDeposit)
+		}
+		if pparams.A0.Cmp(params.A0) != 0 || pparams.Rho.Sign() != 0 {
+			t.Fatalf("did not get expected a0/rho: got %s/%s", pparams.A0, pparams.Rho)
+		}
+		if pparams.ProtocolVersion.Major != 10 {
+			t.Fatalf("did not get expected protocol version: got %d", pparams.ProtocolVersion.Major)
+		}
+		if len(pparams.CostModels[0]) != 2 {
+			t.Fatalf("did not get expected cost models: got %v", pparams.CostModels)
+		}
+		if pparams.ExecutionCosts.StepPrice.Cmp(params.ExecutionCosts.StepPrice) != 0 {
+			t.Fatalf("did not get expected step price: got %s", pparams.ExecutionCosts.StepPrice)
+		}
+		if pparams.MaxTxExUnits != params.MaxTxExUnits {
+			t.Fatalf("did not get expected max tx ex units: got %+v", pparams.MaxTxExUnits)
+		}
+		if pparams.PoolVotingThresholds.HardForkInitiation.Cmp(big.NewRat(51, 100)) != 0 {
+			t.Fatalf("did not get expected pool hard fork threshold: got %s", pparams.PoolVotingThresholds.HardForkInitiation)
+		}
+		if pparams.DRepVotingThresholds.UpdateToConstitution.Cmp(big.NewRat(3, 4)) != 0 ||
+			pparams.DRepVotingThresholds.TreasuryWithdrawal.Cmp(big.NewRat(67, 100)) != 0 {
+			t.Fatalf("did not get expected DRep thresholds: got %+v", pparams.DRepVotingThresholds)
+		}
+		if pparams.MinCommitteeSize != 7 || pparams.CommitteeTermLimit != 146 {
+			t.Fatalf("did not get expected committee params: got %d/%d", pparams.MinCommitteeSize, pparams.CommitteeTermLimit)
+		}
+		if pparams.GovActionDeposit != params.GovActionDeposit || pparams.DRepDeposit != params.DRepDeposit {
+			t.Fatalf("did not get expected deposits: got %d/%d", pparams.GovActionDeposit, pparams.DRepDeposit)
+		}
+		if pparams.DRepInactivityPeriod != 20 || pparams.GovActionValidityPeriod != 6 {
+			t.Fatalf("did not get expected periods: got %d/%d", pparams.DRepInactivityPeriod, pparams.GovActionValidityPeriod)
+		}
+		if pparams.MinFeeRefScriptCostPerByte.Cmp(big.NewRat(15, 1)) != 0 {
+			t.Fatalf("did not get expected ref script cost: got %s", pparams.MinFeeRefScriptCostPerByte)
+		}
+	})
+}
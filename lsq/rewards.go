@@ -0,0 +1,70 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// RewardAccount holds the delegation and reward balance for a stake credential
+type RewardAccount struct {
+	Credential common.StakeCredential
+	// PoolId is the pool that the credential is delegated to, or nil if the credential isn't delegated
+	PoolId  *common.PoolId
+	Rewards uint64
+}
+
+// NewFilteredDelegationsAndRewardAccountsQuery returns a conversation entry that matches a query for the
+// delegations and reward balances of the provided stake credentials in the specified era
+func NewFilteredDelegationsAndRewardAccountsQuery(
+	era uint,
+	creds []common.StakeCredential,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			localstatequery.QueryTypeShelleyFilteredDelegationAndRewardAccounts,
+			credentialSet(creds),
+		),
+	)
+}
+
+// NewFilteredDelegationsAndRewardAccountsResult returns a conversation entry for a delegations and reward
+// accounts query result containing the provided accounts. Accounts without a pool are only included in the
+// reward balances
+func NewFilteredDelegationsAndRewardAccountsResult(
+	accounts []RewardAccount,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	delegations := map[*common.StakeCredential]common.PoolId{}
+	rewards := map[*common.StakeCredential]uint64{}
+	for idx := range accounts {
+		account := &accounts[idx]
+		if account.PoolId != nil {
+			delegations[&account.Credential] = *account.PoolId
+		}
+		rewards[&account.Credential] = account.Rewards
+	}
+	return NewConversationEntryResult(
+		[]any{
+			[]any{
+				delegations,
+				rewards,
+			},
+		},
+	)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+)
+
+func TestFilteredDelegationsAndRewardAccountsResult(t *testing.T) {
+	poolId := common.PoolId{0x01}
+	accounts := []lsq.RewardAccount{
+		{
+			Credential: common.StakeCredential{
+				CredType:   common.StakeCredentialTypeAddrKeyHash,
+				Credential: bytes.Repeat([]byte{0xaa}, 28),
+			},
+			PoolId:  &poolId,
+			Rewards: 1_234_567,
+		},
+		{
+			Credential: common.StakeCredential{
+				CredType:   common.StakeCredentialTypeScriptHash,
+				Credential: bytes.Repeat([]byte{0xbb}, 28),
+			},
+			Rewards: 42,
+		},
+	}
+	entry, err := lsq.NewFilteredDelegationsAndRewardAccountsResult(accounts)
+	if err != nil {
+		t.Fatalf("unexpected error building result: %s", err)
+	}
+	var result struct {
+		cbor.StructAsArray
+		Inner struct {
+			cbor.StructAsArray
+			Delegations map[*common.StakeCredential]common.PoolId
+			Rewards     map[*common.StakeCredential]uint64
+		}
+	}
+	decodeResult(t, entry, &result)
+	if len(result.Inner.Delegations) != 1 {
+		t.Fatalf("did not get expected number of delegations: got %d, expected %d", len(result.Inner.Delegations), 1)
+	}
+	for cred, delegatedPoolId := range result.Inner.Delegations {
+		if !bytes.Equal(cred.Credential, accounts[0].Credential.Credential) || delegatedPoolId != poolId {
+			t.Fatalf("did not get expected delegation: %x => %s", cred.Credential, delegatedPoolId)
+		}
+	}
+	if len(result.Inner.Rewards) != len(accounts) {
+		t.Fatalf("did not get expected number of reward accounts: got %d, expected %d", len(result.Inner.Rewards), len(accounts))
+	}
+	for cred, rewards := range result.Inner.Rewards {
+		for _, account := range accounts {
+			if cred.CredType == account.Credential.CredType && rewards != account.Rewards {
+				t.Fatalf("did not get expected rewards for %x: got %d, expected %d", cred.Credential, rewards, account.Rewards)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// NewSystemStartQuery returns a conversation entry that matches a query for the system start time
+func NewSystemStartQuery() (ouroboros_mock.ConversationEntryInput, error) {
+	return NewConversationEntryQuery(
+		buildQuery(localstatequery.QueryTypeSystemStart),
+	)
+}
+
+// NewSystemStartResult returns a conversation entry for a system start query result with the provided time
+func NewSystemStartResult(
+	start time.Time,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return NewConversationEntryResult(systemStartResult(start))
+}
+
+// NewSystemStartNowResult returns a conversation entry for a system start query result with the time that the
+// result is sent, minus the provided offset. This simulates a network which started the specified duration before
+// each query
+func NewSystemStartNowResult(
+	offset time.Duration,
+) ouroboros_mock.ConversationEntryOutput {
+	return NewConversationEntryLazyResult(
+		func() (any, error) {
+			return systemStartResult(time.Now().Add(-offset)), nil
+		},
+	)
+}
+
+// systemStartResult returns the provided time as a year, day of the year, and picoseconds of the day in UTC
+func systemStartResult(start time.Time) localstatequery.SystemStartResult {
+	start = start.UTC()
+	dayStart := time.Date(
+		start.Year(),
+		start.Month(),
+		start.Day(),
+		0,
+		0,
+		0,
+		0,
+		time.UTC,
+	)
+	return localstatequery.SystemStartResult{
+		Year:        start.Year(),
+		Day:         start.YearDay(),
+		Picoseconds: uint64(start.Sub(dayStart).Nanoseconds()) * 1000,
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"go.uber.org/goleak"
+)
+
+// systemStartTime converts a system start query result back to a time
+func systemStartTime(result *localstatequery.SystemStartResult) time.Time {
+	return time.Date(result.Year, time.January, 1, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, result.Day-1).
+		Add(time.Duration(result.Picoseconds/1000) * time.Nanosecond)
+}
+
+func TestSystemStart(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// Mainnet system start
+	start := time.Date(2017, time.September, 23, 21, 44, 51, 0, time.UTC)
+	conversation := newTestConversation(t)
+	conversation.add(lsq.NewSystemStartQuery())
+	conversation.add(lsq.NewSystemStartResult(start))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetSystemStart()
+		if err != nil {
+			t.Fatalf("unexpected error querying system start: %s", err)
+		}
+		if result.Year != 2017 || result.Day != 266 || result.Picoseconds != 78291000000000000 {
+			t.Fatalf("did not get expected system start: %#v", result)
+		}
+	})
+}
+
+func TestSystemStartNow(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	offset := time.Hour
+	conversation := newTestConversation(t)
+	conversation.add(lsq.NewSystemStartQuery())
+	conversation.add(lsq.NewSystemStartNowResult(offset), nil)
+	// The result is built when it's sent rather than when the conversation is created
+	time.Sleep(100 * time.Millisecond)
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		before := time.Now().Add(-offset)
+		result, err := oConn.LocalStateQuery().Client.GetSystemStart()
+		if err != nil {
+			t.Fatalf("unexpected error querying system start: %s", err)
+		}
+		after := time.Now().Add(-offset)
+		resultTime := systemStartTime(result)
+		if resultTime.Before(before) || resultTime.After(after) {
+			t.Fatalf("system start %s was not between %s and %s", resultTime, before, after)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq
+
+import (
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// utxoId is the map key used for a UTxO in query results
+type utxoId struct {
+	cbor.StructAsArray
+	TxId        common.Blake2b256
+	OutputIndex uint32
+}
+
+// NewUTxOByAddressQuery returns a conversation entry that matches a query for the UTxOs at the provided addresses
+// in the specified era
+func NewUTxOByAddressQuery(
+	era uint,
+	addrs []common.Address,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	tmpAddrs := make([]*common.Address, len(addrs))
+	for idx := range addrs {
+		tmpAddrs[idx] = &addrs[idx]
+	}
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			localstatequery.QueryTypeShelleyUtxoByAddress,
+			tmpAddrs,
+		),
+	)
+}
+
+// NewUTxOByAddressResult returns a conversation entry for a UTxO by address query result containing the provided
+// UTxOs
+func NewUTxOByAddressResult(
+	utxos []common.Utxo,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return newUtxoResult(utxos)
+}
+
+// NewUTxOByTxInQuery returns a conversation entry that matches a query for the UTxOs with the provided
+// transaction inputs in the specified era
+func NewUTxOByTxInQuery(
+	era uint,
+	txIns []common.TransactionInput,
+) (ouroboros_mock.ConversationEntryInput, error) {
+	tmpTxIns := make([]any, len(txIns))
+	for idx, txIn := range txIns {
+		tmpTxIns[idx] = []any{txIn.Id(), txIn.Index()}
+	}
+	return NewConversationEntryQuery(
+		buildShelleyQuery(
+			era,
+			localstatequery.QueryTypeShelleyUtxoByTxin,
+			tmpTxIns,
+		),
+	)
+}
+
+// NewUTxOByTxInResult returns a conversation entry for a UTxO by transaction input query result containing the
+// provided UTxOs
+func NewUTxOByTxInResult(
+	utxos []common.Utxo,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	return newUtxoResult(utxos)
+}
+
+// newUtxoResult returns a conversation entry for a query result containing the provided UTxOs keyed by their
+// transaction input. Outputs decoded from CBOR are sent with their original CBOR
+func newUtxoResult(
+	utxos []common.Utxo,
+) (ouroboros_mock.ConversationEntryOutput, error) {
+	result := map[utxoId]cbor.RawMessage{}
+	for _, utxo := range utxos {
+		outputCbor := utxo.Output.Cbor()
+		if outputCbor == nil {
+			var err error
+			outputCbor, err = cbor.Encode(utxo.Output)
+			if err != nil {
+				return ouroboros_mock.ConversationEntryOutput{}, err
+			}
+		}
+		key := utxoId{
+			TxId:        utxo.Id.Id(),
+			OutputIndex: utxo.Id.Index(),
+		}
+		result[key] = cbor.RawMessage(outputCbor)
+	}
+	return NewConversationEntryResult(
+		[]any{result},
+	)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsq_test
+
+import (
+	"testing"
+
+	"github.com/blinklabs-io/ouroboros-mock/lsq"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/ledger/babbage"
+	"github.com/blinklabs-io/gouroboros/ledger/common"
+	"github.com/blinklabs-io/gouroboros/ledger/mary"
+	"github.com/blinklabs-io/gouroboros/ledger/shelley"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"go.uber.org/goleak"
+)
+
+const testPaymentAddress = "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"
+
+func buildTestUtxo(t *testing.T) (common.Address, common.Utxo) {
+	addr, err := common.NewAddress(testPaymentAddress)
+	if err != nil {
+		t.Fatalf("unexpected error decoding address: %s", err)
+	}
+	return addr, common.Utxo{
+		Id: shelley.ShelleyTransactionInput{
+			TxId:        common.Blake2b256{0x01},
+			OutputIndex: 1,
+		},
+		Output: &babbage.BabbageTransactionOutput{
+			OutputAddress: addr,
+			OutputAmount: mary.MaryTransactionOutputValue{
+				Amount: 2_000_000,
+			},
+		},
+	}
+}
+
+func checkUtxoResult(
+	t *testing.T,
+	results map[localstatequery.UtxoId]ledger.BabbageTransactionOutput,
+	utxo common.Utxo,
+) {
+	if len(results) != 1 {
+		t.Fatalf("did not get expected number of UTxOs: got %d, expected %d", len(results), 1)
+	}
+	for utxoId, output := range results {
+		if utxoId.Hash != utxo.Id.Id() || uint32(utxoId.Idx) != utxo.Id.Index() {
+			t.Fatalf("did not get expected UTxO ID: got %s#%d", utxoId.Hash, utxoId.Idx)
+		}
+		if output.Amount() != utxo.Output.Amount() {
+			t.Fatalf("did not get expected amount: got %d, expected %d", output.Amount(), utxo.Output.Amount())
+		}
+		if output.Address().String() != testPaymentAddress {
+			t.Fatalf("did not get expected address: got %s", output.Address().String())
+		}
+	}
+}
+
+func TestUTxOByTxIn(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	_, utxo := buildTestUtxo(t)
+	conversation := newTestConversation(t)
+	conversation.addCurrentEra()
+	conversation.add(lsq.NewUTxOByTxInQuery(testEra, []common.TransactionInput{utxo.Id}))
+	conversation.add(lsq.NewUTxOByTxInResult([]common.Utxo{utxo}))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetUTxOByTxIn([]ledger.TransactionInput{utxo.Id})
+		if err != nil {
+			t.Fatalf("unexpected error querying UTxOs: %s", err)
+		}
+		checkUtxoResult(t, result.Results, utxo)
+	})
+}
+
+func TestUTxOByAddress(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	addr, utxo := buildTestUtxo(t)
+	conversation := newTestConversation(t)
+	conversation.addCurrentEra()
+	conversation.add(lsq.NewUTxOByAddressQuery(testEra, []common.Address{addr}))
+	conversation.add(lsq.NewUTxOByAddressResult([]common.Utxo{utxo}))
+	runQueries(t, conversation.entries, func(oConn *ouroboros.Connection) {
+		result, err := oConn.LocalStateQuery().Client.GetUTxOByAddress([]ledger.Address{addr})
+		if err != nil {
+			t.Fatalf("unexpected error querying UTxOs: %s", err)
+		}
+		checkUtxoResult(t, result.Results, utxo)
+	})
+}
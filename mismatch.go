@@ -0,0 +1,128 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	fxcbor "github.com/fxamacker/cbor/v2"
+)
+
+// MismatchFunc is a function that is called when a message from the client doesn't match the expected
+// conversation entry
+type MismatchFunc func(*InputMismatchError)
+
+// WithOnMismatch specifies a function to call when a message from the client doesn't match the expected
+// conversation entry. The mismatch is also returned as a conversation error
+func WithOnMismatch(mismatchFunc MismatchFunc) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.onMismatch = mismatchFunc
+	}
+}
+
+// InputMismatchError describes a message from the client that doesn't match the expected conversation entry.
+// Expected is only populated when the entry specifies a full message, and Actual is only populated when the entry
+// specifies a full message or a matcher
+type InputMismatchError struct {
+	ProtocolId   uint16
+	ExpectedType uint
+	ActualType   uint
+	Expected     protocol.Message
+	Actual       protocol.Message
+	ExpectedCbor []byte
+	ActualCbor   []byte
+}
+
+func (e *InputMismatchError) Error() string {
+	if e.Expected == nil && e.Actual != nil {
+		return fmt.Sprintf(
+			"parsed message was not accepted by matcher: got %#v",
+			e.Actual,
+		)
+	}
+	if e.Expected == nil {
+		return fmt.Sprintf(
+			"input message is not of expected type: expected %d, got %d",
+			e.ExpectedType,
+			e.ActualType,
+		)
+	}
+	return fmt.Sprintf(
+		"parsed message does not match expected value: got %#v, expected %#v\n%s",
+		e.Actual,
+		e.Expected,
+		e.Diff(),
+	)
+}
+
+// Diff returns the expected and actual message CBOR in diagnostic notation along with the offset of the first
+// differing byte
+func (e *InputMismatchError) Diff() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "expected CBOR: %s\n", diagnoseCbor(e.ExpectedCbor))
+	fmt.Fprintf(&sb, "actual CBOR:   %s\n", diagnoseCbor(e.ActualCbor))
+	offset := 0
+	for offset < len(e.ExpectedCbor) && offset < len(e.ActualCbor) {
+		if e.ExpectedCbor[offset] != e.ActualCbor[offset] {
+			break
+		}
+		offset++
+	}
+	switch {
+	case offset < len(e.ExpectedCbor) && offset < len(e.ActualCbor):
+		fmt.Fprintf(
+			&sb,
+			"first difference at byte %d: expected 0x%02x, got 0x%02x",
+			offset,
+			e.ExpectedCbor[offset],
+			e.ActualCbor[offset],
+		)
+	case len(e.ExpectedCbor) != len(e.ActualCbor):
+		fmt.Fprintf(
+			&sb,
+			"first difference at byte %d: expected %d bytes, got %d bytes",
+			offset,
+			len(e.ExpectedCbor),
+			len(e.ActualCbor),
+		)
+	default:
+		sb.WriteString("CBOR is identical")
+	}
+	return sb.String()
+}
+
+// diagnoseCbor returns the provided CBOR in diagnostic notation, or as hex if it can't be decoded
+func diagnoseCbor(data []byte) string {
+	ret, err := fxcbor.Diagnose(data)
+	if err != nil {
+		return fmt.Sprintf("%x (%s)", data, err)
+	}
+	return ret
+}
+
+// messageCbor returns the CBOR for the provided message, encoding it if necessary
+func messageCbor(msg protocol.Message) []byte {
+	if data := msg.Cbor(); data != nil {
+		return data
+	}
+	data, err := cbor.Encode(msg)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -15,13 +15,32 @@
 package ouroboros_mock_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
 
 	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/handshake"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"github.com/blinklabs-io/gouroboros/protocol/txsubmission"
 	"go.uber.org/goleak"
 )
 
@@ -102,3 +121,1237 @@ func TestError(t *testing.T) {
 		t.Fatalf("did not complete within timeout")
 	}
 }
+
+func TestHandshakeRefuseResourceExhausted(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		ouroboros_mock.ConversationHandshakeNtNRefuseResourceExhausted,
+	)
+	_, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	if !strings.Contains(err.Error(), ouroboros_mock.MockResourceExhaustedReason) {
+		t.Fatalf("did not receive expected error: got %s", err)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestDelayedAcquire(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	delay := 200 * time.Millisecond
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		ouroboros_mock.NewConversationDelayedAcquire(delay),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	startTime := time.Now()
+	if err := oConn.LocalStateQuery().Client.Acquire(nil); err != nil {
+		t.Fatalf("unexpected error when acquiring: %s", err)
+	}
+	if elapsed := time.Since(startTime); elapsed < delay {
+		t.Fatalf("acquire completed sooner than expected: %s", elapsed)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestResetAfterMessages(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			ouroboros_mock.ConversationEntryResetAfterMessages{Count: 1},
+		},
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	if err := oConn.LocalStateQuery().Client.Acquire(nil); err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestSleepInterruptedByClose(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+			ouroboros_mock.ConversationEntrySleep{Duration: time.Hour},
+		},
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Close Ouroboros connection while the mock is sleeping
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+	// Wait for the mock connection to finish
+	select {
+	case <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("mock connection did not stop sleeping after close")
+	}
+}
+
+func TestCorruptedCbor(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.WithCorruptedCbor(
+				ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+				func(payload []byte) []byte {
+					// Replace the message type with an unknown value
+					payload[1] = 0x09
+					return payload
+				},
+			),
+		},
+	)
+	_, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestTruncatedSegment(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.WithTruncatedSegment(
+				ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+				3,
+			),
+		},
+	)
+	// Read the segment directly, since a client would wait for the rest of the message
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(mockConn, header); err != nil {
+		t.Fatalf("unexpected error reading segment header: %s", err)
+	}
+	if payloadLength := binary.BigEndian.Uint16(header[6:]); payloadLength != 3 {
+		t.Fatalf("did not get expected payload length: got %d, expected %d", payloadLength, 3)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestBearerLatency(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	latency := 100 * time.Millisecond
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+		},
+		ouroboros_mock.WithLatency(latency),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	startTime := time.Now()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// The handshake request and response should each be delayed
+	if elapsed := time.Since(startTime); elapsed < 2*latency {
+		t.Fatalf("handshake completed too quickly: got %s, expected at least %s", elapsed, 2*latency)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestBearerLatencyNotCumulative(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	latency := 200 * time.Millisecond
+	segmentCount := 5
+	var conversation []ouroboros_mock.ConversationEntry
+	for i := 0; i < segmentCount; i++ {
+		conversation = append(conversation, ouroboros_mock.ConversationEntryKeepAliveResponse)
+	}
+	startTime := time.Now()
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+		ouroboros_mock.WithLatency(latency),
+	)
+	for i := 0; i < segmentCount; i++ {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(mockConn, header); err != nil {
+			t.Fatalf("unexpected error reading segment header: %s", err)
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+		if _, err := io.ReadFull(mockConn, payload); err != nil {
+			t.Fatalf("unexpected error reading segment payload: %s", err)
+		}
+	}
+	// Segments sent back to back should arrive together after a single latency period
+	elapsed := time.Since(startTime)
+	if elapsed < latency {
+		t.Fatalf("segments arrived too quickly: got %s, expected at least %s", elapsed, latency)
+	}
+	if elapsed >= 2*latency {
+		t.Fatalf("segments arrived too slowly: got %s, expected less than %s", elapsed, 2*latency)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestInputMismatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mismatchChan := make(chan *ouroboros_mock.InputMismatchError, 1)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryInput{
+				ProtocolId: handshake.ProtocolId,
+				Message: handshake.NewMsgProposeVersions(
+					protocol.ProtocolVersionMap{
+						ouroboros_mock.MockProtocolVersionNtC: protocol.VersionDataNtC9to14(
+							ouroboros_mock.MockNetworkMagic,
+						),
+					},
+				),
+				MsgFromCborFunc: handshake.NewMsgFromCbor,
+			},
+		},
+		ouroboros_mock.WithOnMismatch(
+			func(mismatchErr *ouroboros_mock.InputMismatchError) {
+				mismatchChan <- mismatchErr
+			},
+		),
+	)
+	// Async mock connection error handler
+	asyncErrChan := make(chan error, 1)
+	go func() {
+		asyncErrChan <- <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+	}()
+	_, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	// Check the mismatch passed to the callback
+	select {
+	case mismatchErr := <-mismatchChan:
+		if mismatchErr.ProtocolId != handshake.ProtocolId {
+			t.Fatalf("did not get expected protocol ID: got %d, expected %d", mismatchErr.ProtocolId, handshake.ProtocolId)
+		}
+		if mismatchErr.Actual == nil {
+			t.Fatalf("did not get decoded actual message")
+		}
+		if !strings.Contains(mismatchErr.Diff(), "first difference at byte") {
+			t.Fatalf("did not get expected CBOR diff: %s", mismatchErr.Diff())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("mismatch function was not called within timeout")
+	}
+	// Check the mismatch returned as a conversation error
+	select {
+	case err := <-asyncErrChan:
+		var mismatchErr *ouroboros_mock.InputMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("did not receive expected error type: got %T: %s", err, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("did not receive conversation error within timeout")
+	}
+}
+
+func TestInterleavedProtocols(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryKeepAliveRequest,
+			ouroboros_mock.ConversationEntryKeepAliveResponse,
+		},
+		ouroboros_mock.WithInterleavedProtocols(),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	// Send an unscripted TxSubmission Init message before the expected KeepAlive message
+	writeSegment(t, mockConn, txsubmission.ProtocolId, txsubmission.NewMsgInit())
+	writeSegment(t, mockConn, keepalive.ProtocolId, keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(mockConn, header); err != nil {
+		t.Fatalf("unexpected error reading segment header: %s", err)
+	}
+	if protocolId := binary.BigEndian.Uint16(header[4:6]) & 0x7fff; protocolId != keepalive.ProtocolId {
+		t.Fatalf("did not get expected protocol ID: got %d, expected %d", protocolId, keepalive.ProtocolId)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+	if _, err := io.ReadFull(mockConn, payload); err != nil {
+		t.Fatalf("unexpected error reading segment payload: %s", err)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+// writeSegment writes a segment containing the provided message to the connection
+func writeSegment(t *testing.T, conn net.Conn, protocolId uint16, msg protocol.Message) {
+	t.Helper()
+	payload, err := cbor.Encode(msg)
+	if err != nil {
+		t.Fatalf("unexpected error encoding message: %s", err)
+	}
+	segment := muxer.NewSegment(protocolId, payload, false)
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.BigEndian, segment.SegmentHeader); err != nil {
+		t.Fatalf("unexpected error encoding segment header: %s", err)
+	}
+	buf.Write(segment.Payload)
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error writing segment: %s", err)
+	}
+}
+
+func TestInterleavedProtocolsMaxPendingInput(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryInput{
+				ProtocolId:  chainsync.ProtocolIdNtN,
+				MessageType: chainsync.MessageTypeFindIntersect,
+			},
+		},
+		ouroboros_mock.WithInterleavedProtocols(),
+	)
+	// Send more unscripted KeepAlive messages than can be buffered in a single segment
+	payload := bytes.NewBuffer(nil)
+	for i := 0; i <= ouroboros_mock.MaxPendingInput; i++ {
+		msgCbor, err := cbor.Encode(keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie))
+		if err != nil {
+			t.Fatalf("unexpected error encoding message: %s", err)
+		}
+		payload.Write(msgCbor)
+	}
+	segment := muxer.NewSegment(keepalive.ProtocolId, payload.Bytes(), false)
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.BigEndian, segment.SegmentHeader); err != nil {
+		t.Fatalf("unexpected error encoding segment header: %s", err)
+	}
+	buf.Write(segment.Payload)
+	if _, err := mockConn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error writing segment: %s", err)
+	}
+	waitConversationError(t, mockConn, "too many unprocessed messages")
+}
+
+func TestInputMatcher(t *testing.T) {
+	testDefs := []struct {
+		name        string
+		slot        uint64
+		expectMatch bool
+	}{
+		{name: "Match", slot: 1001, expectMatch: true},
+		{name: "NoMatch", slot: 500, expectMatch: false},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				[]ouroboros_mock.ConversationEntry{
+					ouroboros_mock.ConversationEntryInput{
+						ProtocolId:      chainsync.ProtocolIdNtN,
+						MsgFromCborFunc: chainsync.NewMsgFromCborNtN,
+						Matcher: func(msg protocol.Message) bool {
+							// Accept any FindIntersect whose first point is after slot 1000
+							msgFindIntersect, ok := msg.(*chainsync.MsgFindIntersect)
+							if !ok || len(msgFindIntersect.Points) == 0 {
+								return false
+							}
+							return msgFindIntersect.Points[0].Slot > 1000
+						},
+					},
+				},
+			)
+			writeSegment(
+				t,
+				mockConn,
+				chainsync.ProtocolIdNtN,
+				chainsync.NewMsgFindIntersect(
+					[]common.Point{
+						common.NewPoint(testDef.slot, make([]byte, 32)),
+					},
+				),
+			)
+			select {
+			case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+				if testDef.expectMatch && ok {
+					t.Fatalf("unexpected conversation error: %s", err)
+				}
+				if !testDef.expectMatch {
+					var mismatchErr *ouroboros_mock.InputMismatchError
+					if !errors.As(err, &mismatchErr) {
+						t.Fatalf("did not receive expected error type: got %T: %s", err, err)
+					}
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("conversation did not finish within timeout")
+			}
+			if err := mockConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing mock connection: %s", err)
+			}
+		})
+	}
+}
+
+func TestBranch(t *testing.T) {
+	knownPoint := common.NewPoint(1234, make([]byte, 32))
+	tip := chainsync.Tip{Point: knownPoint, BlockNumber: 10}
+	testDefs := []struct {
+		name            string
+		point           common.Point
+		expectedMsgType uint
+	}{
+		{
+			name:            "KnownPoint",
+			point:           knownPoint,
+			expectedMsgType: chainsync.MessageTypeIntersectFound,
+		},
+		{
+			name:            "UnknownPoint",
+			point:           common.NewPoint(999, make([]byte, 32)),
+			expectedMsgType: chainsync.MessageTypeIntersectNotFound,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				[]ouroboros_mock.ConversationEntry{
+					ouroboros_mock.ConversationEntryBranch{
+						ProtocolId:      chainsync.ProtocolIdNtN,
+						MsgFromCborFunc: chainsync.NewMsgFromCborNtN,
+						Predicate: func(msg protocol.Message) bool {
+							msgFindIntersect, ok := msg.(*chainsync.MsgFindIntersect)
+							if !ok {
+								return false
+							}
+							return slices.ContainsFunc(
+								msgFindIntersect.Points,
+								func(point common.Point) bool {
+									return point.Slot == knownPoint.Slot
+								},
+							)
+						},
+						Then: []ouroboros_mock.ConversationEntry{
+							ouroboros_mock.ConversationEntryOutput{
+								ProtocolId: chainsync.ProtocolIdNtN,
+								IsResponse: true,
+								Messages: []protocol.Message{
+									chainsync.NewMsgIntersectFound(knownPoint, tip),
+								},
+							},
+						},
+						Else: []ouroboros_mock.ConversationEntry{
+							ouroboros_mock.ConversationEntryOutput{
+								ProtocolId: chainsync.ProtocolIdNtN,
+								IsResponse: true,
+								Messages: []protocol.Message{
+									chainsync.NewMsgIntersectNotFound(tip),
+								},
+							},
+						},
+					},
+				},
+			)
+			// Async mock connection error handler
+			go func() {
+				err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+				if ok {
+					panic(err)
+				}
+			}()
+			writeSegment(
+				t,
+				mockConn,
+				chainsync.ProtocolIdNtN,
+				chainsync.NewMsgFindIntersect([]common.Point{testDef.point}),
+			)
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(mockConn, header); err != nil {
+				t.Fatalf("unexpected error reading segment header: %s", err)
+			}
+			payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+			if _, err := io.ReadFull(mockConn, payload); err != nil {
+				t.Fatalf("unexpected error reading segment payload: %s", err)
+			}
+			msgType, err := cbor.DecodeIdFromList(payload)
+			if err != nil {
+				t.Fatalf("unexpected error decoding message type: %s", err)
+			}
+			if uint(msgType) != testDef.expectedMsgType {
+				t.Fatalf("did not get expected message type: got %d, expected %d", msgType, testDef.expectedMsgType)
+			}
+			if err := mockConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing mock connection: %s", err)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	testDefs := []struct {
+		name         string
+		conversation []ouroboros_mock.ConversationEntry
+	}{
+		{
+			name: "InputOutput",
+			conversation: []ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		},
+		{
+			name: "HandshakeNegotiate",
+			conversation: []ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeNegotiate{},
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			testStats(t, testDef.conversation)
+		})
+	}
+}
+
+func testStats(t *testing.T, conversation []ouroboros_mock.ConversationEntry) {
+	defer goleak.VerifyNone(t)
+	var entryCount atomic.Int32
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+		ouroboros_mock.WithOnEntry(
+			func(ouroboros_mock.ConversationEntry) {
+				entryCount.Add(1)
+			},
+		),
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	stats := mockConn.(*ouroboros_mock.Connection).Stats()
+	if stats.EntriesProcessed != len(conversation) {
+		t.Fatalf("did not get expected entries processed: got %d, expected %d", stats.EntriesProcessed, len(conversation))
+	}
+	if count := int(entryCount.Load()); count != len(conversation) {
+		t.Fatalf("did not get expected entry callback count: got %d, expected %d", count, len(conversation))
+	}
+	proposeKey := ouroboros_mock.MessageKey{
+		ProtocolId:  handshake.ProtocolId,
+		MessageType: handshake.MessageTypeProposeVersions,
+	}
+	if count := stats.MessagesIn[proposeKey]; count != 1 {
+		t.Fatalf("did not get expected ProposeVersions count: got %d, expected %d", count, 1)
+	}
+	acceptKey := ouroboros_mock.MessageKey{
+		ProtocolId:  handshake.ProtocolId,
+		MessageType: handshake.MessageTypeAcceptVersion,
+	}
+	if count := stats.MessagesOut[acceptKey]; count != 1 {
+		t.Fatalf("did not get expected AcceptVersion count: got %d, expected %d", count, 1)
+	}
+	if stats.BytesIn == 0 || stats.BytesOut == 0 {
+		t.Fatalf("did not get expected byte counts: in %d, out %d", stats.BytesIn, stats.BytesOut)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+func TestStatsMultipleMessagesInSegment(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryKeepAliveRequest,
+			ouroboros_mock.ConversationEntryKeepAliveRequest,
+		},
+	)
+	// Send both messages in a single segment
+	msgCbor, err := cbor.Encode(keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie))
+	if err != nil {
+		t.Fatalf("unexpected error encoding message: %s", err)
+	}
+	payload := append(slices.Clone(msgCbor), msgCbor...)
+	segment := muxer.NewSegment(keepalive.ProtocolId, payload, false)
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.BigEndian, segment.SegmentHeader); err != nil {
+		t.Fatalf("unexpected error encoding segment header: %s", err)
+	}
+	buf.Write(segment.Payload)
+	if _, err := mockConn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error writing segment: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	stats := mockConn.(*ouroboros_mock.Connection).Stats()
+	if stats.BytesIn != uint64(buf.Len()) {
+		t.Fatalf("did not get expected bytes in: got %d, expected %d", stats.BytesIn, buf.Len())
+	}
+	keepAliveKey := ouroboros_mock.MessageKey{
+		ProtocolId:  keepalive.ProtocolId,
+		MessageType: keepalive.MessageTypeKeepAlive,
+	}
+	if count := stats.MessagesIn[keepAliveKey]; count != 2 {
+		t.Fatalf("did not get expected KeepAlive count: got %d, expected %d", count, 2)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	var traceBuf syncBuffer
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+		},
+		ouroboros_mock.WithTrace(&traceBuf),
+	)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	lines := strings.Split(strings.TrimSpace(traceBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("did not get expected number of trace lines: got %d, expected %d", len(lines), 2)
+	}
+	expectedEvents := []struct {
+		direction   string
+		messageType uint
+	}{
+		{ouroboros_mock.TraceDirectionIn, handshake.MessageTypeProposeVersions},
+		{ouroboros_mock.TraceDirectionOut, handshake.MessageTypeAcceptVersion},
+	}
+	for idx, line := range lines {
+		var event ouroboros_mock.TraceEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("unexpected error decoding trace line: %s", err)
+		}
+		if event.Direction != expectedEvents[idx].direction {
+			t.Fatalf("did not get expected direction: got %s, expected %s", event.Direction, expectedEvents[idx].direction)
+		}
+		if event.MessageType == nil || *event.MessageType != expectedEvents[idx].messageType {
+			t.Fatalf("did not get expected message type: got %v, expected %d", event.MessageType, expectedEvents[idx].messageType)
+		}
+		if event.Cbor == "" {
+			t.Fatalf("did not get message CBOR")
+		}
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestTimeScale(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	sendAfter := 400 * time.Millisecond
+	response := ouroboros_mock.ConversationEntryHandshakeNtCResponse
+	response.SendAfter = sendAfter
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			response,
+		},
+		ouroboros_mock.WithTimeScale(0.25),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	startTime := time.Now()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// The response should be delayed by the scaled duration
+	elapsed := time.Since(startTime)
+	if elapsed < sendAfter/4 || elapsed >= sendAfter {
+		t.Fatalf("handshake did not complete after the scaled delay: got %s, expected %s", elapsed, sendAfter/4)
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	select {
+	case <-oConn.ErrorChan():
+	case <-time.After(10 * time.Second):
+		t.Errorf("did not shutdown within timeout")
+	}
+}
+
+func TestOutputMinGap(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	minGap := 100 * time.Millisecond
+	response := ouroboros_mock.ConversationEntryKeepAliveResponse
+	gapResponse := ouroboros_mock.ConversationEntryKeepAliveResponse
+	gapResponse.MinGap = minGap
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			response,
+			gapResponse,
+		},
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	var receiveTimes []time.Time
+	for i := 0; i < 2; i++ {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(mockConn, header); err != nil {
+			t.Fatalf("unexpected error reading segment header: %s", err)
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+		if _, err := io.ReadFull(mockConn, payload); err != nil {
+			t.Fatalf("unexpected error reading segment payload: %s", err)
+		}
+		receiveTimes = append(receiveTimes, time.Now())
+	}
+	if gap := receiveTimes[1].Sub(receiveTimes[0]); gap < minGap {
+		t.Fatalf("segments were sent too close together: got %s, expected at least %s", gap, minGap)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestConversationFromTrace(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// Record a handshake
+	var traceBuf syncBuffer
+	recordConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+			ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+		},
+		ouroboros_mock.WithTrace(&traceBuf),
+	)
+	recordOConn, err := ouroboros.New(
+		ouroboros.WithConnection(recordConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the recorded conversation to complete
+	select {
+	case err, ok := <-recordConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	if err := recordOConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	conversation, err := ouroboros_mock.NewConversationFromTrace(
+		strings.NewReader(traceBuf.String()),
+		ouroboros_mock.ProtocolRoleClient,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building conversation from trace: %s", err)
+	}
+	if len(conversation) != 2 {
+		t.Fatalf("did not get expected number of entries: got %d, expected 2", len(conversation))
+	}
+	if _, ok := conversation[1].(ouroboros_mock.ConversationEntryOutput); !ok {
+		t.Fatalf("did not get expected output entry: %T", conversation[1])
+	}
+	// Replay the handshake to a new client
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		conversation,
+		ouroboros_mock.WithTimeScale(0),
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(mockConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Close Ouroboros connections
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// Wait for connection shutdown
+	for _, conn := range []*ouroboros.Connection{recordOConn, oConn} {
+		select {
+		case <-conn.ErrorChan():
+		case <-time.After(10 * time.Second):
+			t.Errorf("did not shutdown within timeout")
+		}
+	}
+}
+
+func TestConversationFromTraceSendAfter(t *testing.T) {
+	trace := strings.Join(
+		[]string{
+			`{"timestamp":"2024-01-01T00:00:00Z","direction":"in","protocol_id":8,"message_type":0,"cbor":"82001903e7"}`,
+			`{"timestamp":"2024-01-01T00:00:00.1Z","direction":"out","protocol_id":8,"message_type":1,"cbor":"82011903e7"}`,
+		},
+		"\n",
+	)
+	conversation, err := ouroboros_mock.NewConversationFromTrace(
+		strings.NewReader(trace),
+		ouroboros_mock.ProtocolRoleClient,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building conversation from trace: %s", err)
+	}
+	input, ok := conversation[0].(ouroboros_mock.ConversationEntryInput)
+	if !ok || input.ProtocolId != keepalive.ProtocolId || input.MessageType != keepalive.MessageTypeKeepAlive || input.IsResponse {
+		t.Fatalf("did not get expected input entry: %#v", conversation[0])
+	}
+	output, ok := conversation[1].(ouroboros_mock.ConversationEntryOutput)
+	if !ok || !output.IsResponse {
+		t.Fatalf("did not get expected output entry: %#v", conversation[1])
+	}
+	if output.SendAfter != 100*time.Millisecond {
+		t.Fatalf("did not get expected send delay: got %s, expected %s", output.SendAfter, 100*time.Millisecond)
+	}
+}
+
+// waitConversationError waits for the mock connection to report an error and checks that it contains the expected
+// text
+func waitConversationError(t *testing.T, mockConn net.Conn, expectedErr string) {
+	t.Helper()
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if !ok {
+			t.Fatalf("did not receive expected error")
+		}
+		if !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("did not receive expected error\n  got:    %s\n  wanted: %s", err, expectedErr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("did not receive error within timeout")
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+		},
+		ouroboros_mock.WithContext(ctx),
+	)
+	// Cancelling the context stops the conversation while it waits for input
+	cancel()
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not stop within timeout")
+	}
+	if _, err := mockConn.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("did not receive expected error reading from closed connection")
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	testDefs := []struct {
+		name  string
+		entry ouroboros_mock.ConversationEntry
+	}{
+		{
+			name:  "Input",
+			entry: ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+		},
+		{
+			name:  "HandshakeNegotiate",
+			entry: ouroboros_mock.ConversationEntryHandshakeNegotiate{},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				[]ouroboros_mock.ConversationEntry{
+					testDef.entry,
+				},
+				ouroboros_mock.WithReadTimeout(100*time.Millisecond),
+			)
+			waitConversationError(t, mockConn, "read timeout: no message received within 100ms")
+		})
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// The client never reads the response
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryKeepAliveResponse,
+		},
+		ouroboros_mock.WithWriteTimeout(100*time.Millisecond),
+	)
+	waitConversationError(t, mockConn, "write timeout: segment not accepted within 100ms")
+}
+
+func TestMaxSegmentSize(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	maxSegmentSize := 4
+	response := ouroboros_mock.ConversationEntryKeepAliveResponse
+	expectedPayload, err := cbor.Encode(response.Messages[0])
+	if err != nil {
+		t.Fatalf("unexpected error encoding message: %s", err)
+	}
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			response,
+		},
+		ouroboros_mock.WithMaxSegmentSize(maxSegmentSize),
+	)
+	var payload []byte
+	var wireBytes int
+	for len(payload) < len(expectedPayload) {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(mockConn, header); err != nil {
+			t.Fatalf("unexpected error reading segment header: %s", err)
+		}
+		segmentSize := int(binary.BigEndian.Uint16(header[6:]))
+		if segmentSize > maxSegmentSize {
+			t.Fatalf("segment was larger than maximum: got %d, expected at most %d", segmentSize, maxSegmentSize)
+		}
+		segmentPayload := make([]byte, segmentSize)
+		if _, err := io.ReadFull(mockConn, segmentPayload); err != nil {
+			t.Fatalf("unexpected error reading segment payload: %s", err)
+		}
+		payload = append(payload, segmentPayload...)
+		wireBytes += len(header) + segmentSize
+	}
+	if !bytes.Equal(payload, expectedPayload) {
+		t.Fatalf("did not get expected payload: got %x, expected %x", payload, expectedPayload)
+	}
+	// Wait for the conversation to complete
+	select {
+	case err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan():
+		if ok {
+			t.Fatalf("unexpected conversation error: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// The byte count includes the header for each segment
+	if bytesOut := mockConn.(*ouroboros_mock.Connection).Stats().BytesOut; bytesOut != uint64(wireBytes) {
+		t.Fatalf("did not get expected bytes out: got %d, expected %d", bytesOut, wireBytes)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
+
+func TestHandshakeQuery(t *testing.T) {
+	queryVersionData := protocol.VersionDataNtN13andUp{
+		VersionDataNtN11to12: protocol.VersionDataNtN11to12{
+			CborNetworkMagic:                       ouroboros_mock.MockNetworkMagic,
+			CborInitiatorAndResponderDiffusionMode: protocol.DiffusionModeInitiatorOnly,
+			CborPeerSharing:                        protocol.PeerSharingModeNoPeerSharing,
+			CborQuery:                              protocol.QueryModeEnabled,
+		},
+	}
+	testDefs := []struct {
+		name             string
+		entry            ouroboros_mock.ConversationEntryHandshakeNegotiate
+		expectedVersions []uint16
+	}{
+		{
+			name: "Versions",
+			entry: ouroboros_mock.ConversationEntryHandshakeNegotiate{
+				Versions: protocol.ProtocolVersionMap{
+					12: queryVersionData.VersionDataNtN11to12,
+					13: queryVersionData,
+				},
+			},
+			expectedVersions: []uint16{12, 13},
+		},
+		{
+			name: "QueryVersions",
+			entry: ouroboros_mock.ConversationEntryHandshakeNegotiate{
+				QueryVersions: protocol.ProtocolVersionMap{
+					11: queryVersionData.VersionDataNtN11to12,
+					12: queryVersionData.VersionDataNtN11to12,
+					13: queryVersionData,
+					14: queryVersionData,
+				},
+			},
+			expectedVersions: []uint16{11, 12, 13, 14},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				[]ouroboros_mock.ConversationEntry{
+					testDef.entry,
+				},
+			)
+			// Async mock connection error handler
+			go func() {
+				err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+				if ok {
+					panic(err)
+				}
+			}()
+			writeSegment(
+				t,
+				mockConn,
+				handshake.ProtocolId,
+				handshake.NewMsgProposeVersions(
+					protocol.ProtocolVersionMap{
+						13: queryVersionData,
+					},
+				),
+			)
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(mockConn, header); err != nil {
+				t.Fatalf("unexpected error reading segment header: %s", err)
+			}
+			payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+			if _, err := io.ReadFull(mockConn, payload); err != nil {
+				t.Fatalf("unexpected error reading segment payload: %s", err)
+			}
+			var msg ouroboros_mock.MsgHandshakeQueryReply
+			if _, err := cbor.Decode(payload, &msg); err != nil {
+				t.Fatalf("unexpected error decoding QueryReply: %s", err)
+			}
+			if msg.MessageType != ouroboros_mock.MessageTypeHandshakeQueryReply {
+				t.Fatalf("did not get expected message type: got %d, expected %d", msg.MessageType, ouroboros_mock.MessageTypeHandshakeQueryReply)
+			}
+			versions := make([]uint16, 0, len(msg.VersionMap))
+			for version := range msg.VersionMap {
+				versions = append(versions, version)
+			}
+			slices.Sort(versions)
+			if !slices.Equal(versions, testDef.expectedVersions) {
+				t.Fatalf("did not get expected versions: got %v, expected %v", versions, testDef.expectedVersions)
+			}
+			if err := mockConn.Close(); err != nil {
+				t.Fatalf("unexpected error when closing mock connection: %s", err)
+			}
+		})
+	}
+}
+
+func TestStall(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	stallDuration := 200 * time.Millisecond
+	mockConn := ouroboros_mock.NewConnection(
+		ouroboros_mock.ProtocolRoleClient,
+		[]ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryStall{
+				ProtocolId: keepalive.ProtocolId,
+				Duration:   stallDuration,
+			},
+			ouroboros_mock.ConversationEntryKeepAliveResponse,
+		},
+	)
+	// Async mock connection error handler
+	go func() {
+		err, ok := <-mockConn.(*ouroboros_mock.Connection).ErrorChan()
+		if ok {
+			panic(err)
+		}
+	}()
+	startTime := time.Now()
+	writeSegment(t, mockConn, keepalive.ProtocolId, keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(mockConn, header); err != nil {
+		t.Fatalf("unexpected error reading segment header: %s", err)
+	}
+	if elapsed := time.Since(startTime); elapsed < stallDuration {
+		t.Fatalf("response was sent before the end of the stall: got %s, expected at least %s", elapsed, stallDuration)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[6:]))
+	if _, err := io.ReadFull(mockConn, payload); err != nil {
+		t.Fatalf("unexpected error reading segment payload: %s", err)
+	}
+	if err := mockConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing mock connection: %s", err)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"bytes"
+	"fmt"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blockfetch"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+	"github.com/blinklabs-io/ouroboros-mock/handshake"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	gouroboros_blockfetch "github.com/blinklabs-io/gouroboros/protocol/blockfetch"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"github.com/blinklabs-io/gouroboros/protocol/txsubmission"
+)
+
+// NewNodeToNodeServer returns a Server that emulates a NtN full node serving the provided chain. Each connection
+// negotiates a handshake and then answers keepalive, chainsync, blockfetch, and txsubmission messages until the
+// client disconnects. Any additional options are applied after the conversation is configured
+func NewNodeToNodeServer(
+	chain []blocks.Block,
+	opts ...ouroboros_mock.ServerOptionFunc,
+) *ouroboros_mock.Server {
+	serverOpts := []ouroboros_mock.ServerOptionFunc{
+		ouroboros_mock.WithConversationFunc(
+			func(int) []ouroboros_mock.ConversationEntry {
+				return NewNodeToNodeConversation(chain)
+			},
+		),
+	}
+	serverOpts = append(serverOpts, opts...)
+	return ouroboros_mock.NewServer(serverOpts...)
+}
+
+// NewNodeToNodeConversation returns conversation entries for a single connection that emulate a NtN full node
+// serving the provided chain. The chainsync entries keep track of the client's position in the chain, so a new
+// conversation should be created for each connection
+func NewNodeToNodeConversation(
+	chain []blocks.Block,
+) []ouroboros_mock.ConversationEntry {
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryHandshakeNegotiate{
+			Versions: handshake.NewVersionTableNtN(handshake.VersionsNtN()...),
+		},
+		ouroboros_mock.ConversationEntryParallel{
+			Conversations: [][]ouroboros_mock.ConversationEntry{
+				{
+					ouroboros_mock.ConversationEntryLoop{
+						Entries: []ouroboros_mock.ConversationEntry{
+							keepAliveResponder(),
+						},
+					},
+				},
+				{
+					ouroboros_mock.ConversationEntryLoop{
+						Entries: []ouroboros_mock.ConversationEntry{
+							newChainSyncServer(chain).responder(),
+						},
+					},
+				},
+				{
+					ouroboros_mock.ConversationEntryLoop{
+						Entries: []ouroboros_mock.ConversationEntry{
+							blockFetchResponder(chain),
+						},
+					},
+				},
+				{
+					ouroboros_mock.ConversationEntryLoop{
+						Entries: []ouroboros_mock.ConversationEntry{
+							txSubmissionResponder(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// keepAliveResponder returns a conversation entry that answers each keepalive message with the same cookie
+func keepAliveResponder() ouroboros_mock.ConversationEntryResponder {
+	return ouroboros_mock.ConversationEntryResponder{
+		ProtocolId:      keepalive.ProtocolId,
+		MsgFromCborFunc: keepalive.NewMsgFromCbor,
+		ResponseFunc: func(msg protocol.Message) ([]ouroboros_mock.ConversationEntry, error) {
+			switch msg := msg.(type) {
+			case *keepalive.MsgKeepAlive:
+				return []ouroboros_mock.ConversationEntry{
+					ouroboros_mock.ConversationEntryOutput{
+						ProtocolId: keepalive.ProtocolId,
+						IsResponse: true,
+						Messages: []protocol.Message{
+							keepalive.NewMsgKeepAliveResponse(msg.Cookie),
+						},
+					},
+				}, nil
+			case *keepalive.MsgDone:
+				return nil, nil
+			default:
+				return nil, fmt.Errorf("unexpected keepalive message: %T", msg)
+			}
+		},
+	}
+}
+
+// blockFetchResponder returns a conversation entry that serves block ranges from the provided chain
+func blockFetchResponder(chain []blocks.Block) ouroboros_mock.ConversationEntryResponder {
+	ret := blockfetch.ServeRangeFromChain(chain)
+	serveRange := ret.ResponseFunc
+	ret.ResponseFunc = func(msg protocol.Message) ([]ouroboros_mock.ConversationEntry, error) {
+		if _, ok := msg.(*gouroboros_blockfetch.MsgClientDone); ok {
+			return nil, nil
+		}
+		return serveRange(msg)
+	}
+	return ret
+}
+
+// txSubmissionResponder returns a conversation entry that accepts the txsubmission messages from a client. The
+// mock never requests transactions from the client
+func txSubmissionResponder() ouroboros_mock.ConversationEntryResponder {
+	return ouroboros_mock.ConversationEntryResponder{
+		ProtocolId:      txsubmission.ProtocolId,
+		MsgFromCborFunc: txsubmission.NewMsgFromCbor,
+		ResponseFunc: func(msg protocol.Message) ([]ouroboros_mock.ConversationEntry, error) {
+			switch msg.(type) {
+			case *txsubmission.MsgInit, *txsubmission.MsgDone:
+				return nil, nil
+			default:
+				return nil, fmt.Errorf("unexpected txsubmission message: %T", msg)
+			}
+		},
+	}
+}
+
+// chainSyncServer tracks the position of a NtN chainsync client in the chain
+type chainSyncServer struct {
+	chain        []blocks.Block
+	tip          chainsync.Tip
+	cursor       int
+	intersect    *common.Point
+	awaitingNext bool
+}
+
+func newChainSyncServer(chain []blocks.Block) *chainSyncServer {
+	return &chainSyncServer{
+		chain: chain,
+		tip:   blocks.ChainTip(chain),
+	}
+}
+
+// responder returns a conversation entry that answers a chainsync message from the client
+func (s *chainSyncServer) responder() ouroboros_mock.ConversationEntryResponder {
+	return ouroboros_mock.ConversationEntryResponder{
+		ProtocolId:      chainsync.ProtocolIdNtN,
+		MsgFromCborFunc: chainsync.NewMsgFromCborNtN,
+		ResponseFunc:    s.respond,
+	}
+}
+
+func (s *chainSyncServer) respond(
+	msg protocol.Message,
+) ([]ouroboros_mock.ConversationEntry, error) {
+	switch msg := msg.(type) {
+	case *chainsync.MsgFindIntersect:
+		return s.output(s.findIntersect(msg.Points)), nil
+	case *chainsync.MsgRequestNext:
+		// Roll back to the intersect point before sending any blocks
+		if s.intersect != nil {
+			point := *s.intersect
+			s.intersect = nil
+			return s.output(chainsync.NewMsgRollBackward(point, s.tip)), nil
+		}
+		if s.cursor < len(s.chain) {
+			block := s.chain[s.cursor]
+			s.cursor++
+			return []ouroboros_mock.ConversationEntry{
+				blocks.NewChainSyncRollForwardNtNEntry(block, s.tip),
+			}, nil
+		}
+		// The chain doesn't grow, so the client is left waiting after it reaches the tip
+		if s.awaitingNext {
+			return nil, nil
+		}
+		s.awaitingNext = true
+		return s.output(chainsync.NewMsgAwaitReply()), nil
+	case *chainsync.MsgDone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected chainsync message: %T", msg)
+	}
+}
+
+// findIntersect returns the response to a FindIntersect message, using the first of the provided points that is
+// the origin or is in the chain
+func (s *chainSyncServer) findIntersect(points []common.Point) protocol.Message {
+	for _, point := range points {
+		cursor := -1
+		if point.Slot == 0 && len(point.Hash) == 0 {
+			cursor = 0
+		} else {
+			for idx, block := range s.chain {
+				if block.Slot == point.Slot && bytes.Equal(block.Hash, point.Hash) {
+					cursor = idx + 1
+					break
+				}
+			}
+		}
+		if cursor < 0 {
+			continue
+		}
+		s.cursor = cursor
+		s.intersect = &point
+		s.awaitingNext = false
+		return chainsync.NewMsgIntersectFound(point, s.tip)
+	}
+	return chainsync.NewMsgIntersectNotFound(s.tip)
+}
+
+func (s *chainSyncServer) output(msg protocol.Message) []ouroboros_mock.ConversationEntry {
+	return []ouroboros_mock.ConversationEntry{
+		ouroboros_mock.ConversationEntryOutput{
+			ProtocolId: chainsync.ProtocolIdNtN,
+			IsResponse: true,
+			Messages:   []protocol.Message{msg},
+		},
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node_test
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/blocks"
+	"github.com/blinklabs-io/ouroboros-mock/node"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/blockfetch"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"go.uber.org/goleak"
+)
+
+func TestNodeToNodeServer(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	chain, err := blocks.NewMultiEraChainBuilder().
+		AddBlocks(ledger.EraIdBabbage, 5).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error building chain: %s", err)
+	}
+	server := node.NewNodeToNodeServer(chain)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("unexpected error when starting server: %s", err)
+	}
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error when connecting to server: %s", err)
+	}
+	rollForwardChan := make(chan ledger.BlockHeader, len(chain))
+	blockChan := make(chan ledger.Block, len(chain))
+	keepAliveChan := make(chan struct{}, 1)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+		ouroboros.WithNodeToNode(true),
+		ouroboros.WithKeepAlive(true),
+		ouroboros.WithKeepAliveConfig(
+			keepalive.NewConfig(
+				keepalive.WithPeriod(100*time.Millisecond),
+				keepalive.WithKeepAliveResponseFunc(
+					func(keepalive.CallbackContext, uint16) error {
+						select {
+						case keepAliveChan <- struct{}{}:
+						default:
+						}
+						return nil
+					},
+				),
+			),
+		),
+		ouroboros.WithChainSyncConfig(
+			chainsync.NewConfig(
+				chainsync.WithRollBackwardFunc(
+					func(chainsync.CallbackContext, common.Point, chainsync.Tip) error {
+						return nil
+					},
+				),
+				chainsync.WithRollForwardFunc(
+					func(_ chainsync.CallbackContext, _ uint, blockData any, _ chainsync.Tip) error {
+						rollForwardChan <- blockData.(ledger.BlockHeader)
+						return nil
+					},
+				),
+			),
+		),
+		ouroboros.WithBlockFetchConfig(
+			blockfetch.NewConfig(
+				blockfetch.WithBlockFunc(
+					func(_ blockfetch.CallbackContext, _ uint, block ledger.Block) error {
+						blockChan <- block
+						return nil
+					},
+				),
+			),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Sync the headers from the origin
+	if err := oConn.ChainSync().Client.Sync(nil); err != nil {
+		t.Fatalf("unexpected error when starting chainsync: %s", err)
+	}
+	for idx, block := range chain {
+		select {
+		case header := <-rollForwardChan:
+			if header.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("header %d did not have expected hash: got %s, expected %x", idx, header.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive header %d within timeout", idx)
+		}
+	}
+	// Fetch the blocks for the synced headers
+	err = oConn.BlockFetch().Client.GetBlockRange(
+		chain[0].Point(),
+		chain[len(chain)-1].Point(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when requesting range: %s", err)
+	}
+	for idx, block := range chain {
+		select {
+		case blk := <-blockChan:
+			if blk.Hash() != hex.EncodeToString(block.Hash) {
+				t.Fatalf("block %d did not have expected hash: got %s, expected %x", idx, blk.Hash(), block.Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("did not receive block %d within timeout", idx)
+		}
+	}
+	// Keepalive messages are answered
+	select {
+	case <-keepAliveChan:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("did not receive keepalive response within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+	// The conversation completes when the client disconnects
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// DefaultServerAddress is the address used by a Server when none is provided. It uses a random free port
+const DefaultServerAddress = "127.0.0.1:0"
+
+// DefaultServerNetwork is the network used by a Server when none is provided
+const DefaultServerNetwork = "tcp"
+
+// ServerChanSize is the number of values that the channels returned by Server.ErrorChan and Server.CompleteChan
+// hold for a caller that isn't receiving from them. Further values are dropped until the caller catches up
+const ServerChanSize = 64
+
+// Server listens for connections from the code under test and runs a conversation on each of them. Connections
+// can also be provided directly with ServeConn
+type Server struct {
+	network       string
+	address       string
+	conversation  []ConversationEntry
+	conversations [][]ConversationEntry
+	convFunc      ConversationFunc
+	connOpts      []ConnectionOptionFunc
+	tlsConfig     *tls.Config
+	listener      net.Listener
+	connections   map[*Connection]struct{}
+	connCount     int
+	connMutex     sync.Mutex
+	waitGroup     sync.WaitGroup
+	errorChan     chan error
+	completeChan  chan struct{}
+	doneChan      chan any
+	onceClose     sync.Once
+}
+
+// ServerOptionFunc is a function used to modify a Server
+type ServerOptionFunc func(*Server)
+
+// NewServer returns a new Server with the provided options
+func NewServer(opts ...ServerOptionFunc) *Server {
+	s := &Server{
+		network:      DefaultServerNetwork,
+		address:      DefaultServerAddress,
+		connections:  make(map[*Connection]struct{}),
+		errorChan:    make(chan error, ServerChanSize),
+		completeChan: make(chan struct{}, ServerChanSize),
+		doneChan:     make(chan any),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithConversation specifies the conversation entries run on each connection
+func WithConversation(conversation []ConversationEntry) ServerOptionFunc {
+	return func(s *Server) {
+		s.conversation = conversation
+	}
+}
+
+// WithConversations specifies conversations that are assigned to successive connections in turn, starting again
+// with the first once each has been used. It takes precedence over WithConversation
+func WithConversations(conversations ...[]ConversationEntry) ServerOptionFunc {
+	return func(s *Server) {
+		s.conversations = conversations
+	}
+}
+
+// ConversationFunc returns the conversation entries for a new connection. The index counts the connections served
+// by the server, starting from 0, so that successive connections can be given different conversations
+type ConversationFunc func(index int) []ConversationEntry
+
+// WithConversationFunc specifies a function that is called to build the conversation for each connection, so that
+// entries which keep state aren't shared between connections. It takes precedence over WithConversation and
+// WithConversations
+func WithConversationFunc(convFunc ConversationFunc) ServerOptionFunc {
+	return func(s *Server) {
+		s.convFunc = convFunc
+	}
+}
+
+// WithAddress specifies the address to listen on. This is the socket path when listening on a UNIX socket
+func WithAddress(address string) ServerOptionFunc {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithNetwork specifies the network to listen on, such as "tcp" or "unix". Run a separate Server for each listener
+// to serve different conversations on several networks at once, such as NtN over TCP and NtC over a UNIX socket
+func WithNetwork(network string) ServerOptionFunc {
+	return func(s *Server) {
+		s.network = network
+	}
+}
+
+// WithConnectionOptions specifies the options used for each connection
+func WithConnectionOptions(opts ...ConnectionOptionFunc) ServerOptionFunc {
+	return func(s *Server) {
+		s.connOpts = opts
+	}
+}
+
+// WithTLSConfig specifies a TLS config to use when listening, so that clients must connect with TLS
+func WithTLSConfig(tlsConfig *tls.Config) ServerOptionFunc {
+	return func(s *Server) {
+		s.tlsConfig = tlsConfig
+	}
+}
+
+// Listen starts listening for connections
+func (s *Server) Listen() error {
+	if s.listener != nil {
+		return errors.New("server is already listening")
+	}
+	listener, err := net.Listen(s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("listen error: %w", err)
+	}
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+	s.waitGroup.Add(1)
+	go s.acceptLoop()
+	return nil
+}
+
+// Addr returns the address that the server is listening on, or nil if it isn't listening
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// ErrorChan returns a channel that receives any conversation errors. Callers don't need to receive from it, but
+// errors are dropped once ServerChanSize of them are waiting to be received
+func (s *Server) ErrorChan() <-chan error {
+	return s.errorChan
+}
+
+// CompleteChan returns a channel that receives a value for each connection that runs its conversation to the end
+// without error. Callers don't need to receive from it, but values are dropped once ServerChanSize of them are
+// waiting to be received
+func (s *Server) CompleteChan() <-chan struct{} {
+	return s.completeChan
+}
+
+// Close stops listening and closes any active connections
+func (s *Server) Close() error {
+	var retErr error
+	s.onceClose.Do(func() {
+		close(s.doneChan)
+		if s.listener != nil {
+			if err := s.listener.Close(); err != nil {
+				retErr = err
+			}
+		}
+		s.connMutex.Lock()
+		for conn := range s.connections {
+			_ = conn.Close()
+		}
+		s.connMutex.Unlock()
+		s.waitGroup.Wait()
+	})
+	return retErr
+}
+
+func (s *Server) acceptLoop() {
+	defer s.waitGroup.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.doneChan:
+			default:
+				s.sendError(fmt.Errorf("accept error: %w", err))
+			}
+			return
+		}
+		s.ServeConn(conn)
+	}
+}
+
+// ServeConn runs the conversation on the provided connection, such as one end of a net.Pipe. This can be used
+// without calling Listen to avoid binding a socket. The connection is closed when its conversation ends, or when
+// the server is closed
+func (s *Server) ServeConn(conn net.Conn) {
+	c := newConnection(ProtocolRoleClient, s.nextConversation(), s.connOpts...)
+	s.connMutex.Lock()
+	// Don't start new connections after the server is closed
+	select {
+	case <-s.doneChan:
+		s.connMutex.Unlock()
+		_ = conn.Close()
+		return
+	default:
+	}
+	s.connections[c] = struct{}{}
+	s.waitGroup.Add(1)
+	s.connMutex.Unlock()
+	c.start(conn)
+	go func() {
+		defer s.waitGroup.Done()
+		err, ok := <-c.ErrorChan()
+		// Let the simulated bearer deliver the final output with its delay, since closing cuts it short
+		if bearer, isBearer := c.mockConn.(*bearerConn); isBearer {
+			bearer.waitDelivered(s.doneChan)
+		}
+		// Clean up as soon as the conversation ends, so that finished connections don't accumulate
+		_ = c.Close()
+		s.connMutex.Lock()
+		delete(s.connections, c)
+		s.connMutex.Unlock()
+		if ok {
+			s.sendError(err)
+		} else {
+			s.sendComplete()
+		}
+	}()
+}
+
+// nextConversation returns the conversation entries for a new connection
+func (s *Server) nextConversation() []ConversationEntry {
+	s.connMutex.Lock()
+	index := s.connCount
+	s.connCount++
+	s.connMutex.Unlock()
+	switch {
+	case s.convFunc != nil:
+		return s.convFunc(index)
+	case len(s.conversations) > 0:
+		return s.conversations[index%len(s.conversations)]
+	default:
+		return s.conversation
+	}
+}
+
+// sendError reports a conversation error without blocking if the caller isn't receiving errors
+func (s *Server) sendError(err error) {
+	select {
+	case s.errorChan <- err:
+	default:
+	}
+}
+
+// sendComplete reports a completed conversation without blocking if the caller isn't receiving completions
+func (s *Server) sendComplete() {
+	select {
+	case s.completeChan <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,584 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"go.uber.org/goleak"
+)
+
+func TestServer(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		),
+	)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("unexpected error when starting server: %s", err)
+	}
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error when connecting to server: %s", err)
+	}
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	expectedErr := "input error: input message protocol ID did not match expected value: expected 999, got 0"
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryInput{
+					ProtocolId: 999,
+				},
+			},
+		),
+	)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("unexpected error when starting server: %s", err)
+	}
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error when connecting to server: %s", err)
+	}
+	_, err = ouroboros.New(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err == nil {
+		t.Fatalf("did not receive expected error")
+	}
+	select {
+	case err := <-server.ErrorChan():
+		if err.Error() != expectedErr {
+			t.Fatalf("did not receive expected error\n  got:    %s\n  wanted: %s", err, expectedErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("did not receive conversation error within timeout")
+	}
+}
+
+func TestServerServeConn(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		),
+	)
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	clientConn, serverConn := net.Pipe()
+	server.ServeConn(serverConn)
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(clientConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+func TestServerClosesFinishedConnection(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryKeepAliveRequest,
+				ouroboros_mock.ConversationEntryKeepAliveResponse,
+			},
+		),
+	)
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	server.ServeConn(serverConn)
+	writeSegment(
+		t,
+		clientConn,
+		keepalive.ProtocolId,
+		keepalive.NewMsgKeepAlive(ouroboros_mock.MockKeepAliveCookie),
+	)
+	// Read the response and wait for the connection to be closed without closing the server
+	if err := clientConn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("unexpected error setting read deadline: %s", err)
+	}
+	data, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("unexpected error reading from connection: %s", err)
+	}
+	if len(data) == 0 {
+		t.Fatalf("did not receive response before connection was closed")
+	}
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+}
+
+func TestServerUnreadChannels(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryKeepAliveResponse,
+			},
+		),
+	)
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	baseGoroutines := runtime.NumGoroutine()
+	// Serve more connections than the server channels can hold without receiving from either of them
+	connCount := 4 * ouroboros_mock.ServerChanSize
+	for i := 0; i < connCount; i++ {
+		clientConn, serverConn := net.Pipe()
+		server.ServeConn(serverConn)
+		if err := clientConn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("unexpected error setting read deadline: %s", err)
+		}
+		if _, err := io.ReadAll(clientConn); err != nil {
+			t.Fatalf("unexpected error reading from connection %d: %s", i, err)
+		}
+		clientConn.Close()
+	}
+	// Each finished connection should clean up its goroutines rather than block on an unread channel
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseGoroutines {
+		if time.Now().After(deadline) {
+			t.Fatalf(
+				"did not get expected goroutine count: got %d, expected at most %d",
+				runtime.NumGoroutine(),
+				baseGoroutines,
+			)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The completion channel should be full, with the values that didn't fit dropped
+	if len(server.CompleteChan()) != ouroboros_mock.ServerChanSize {
+		t.Fatalf(
+			"did not get expected completions: got %d, expected %d",
+			len(server.CompleteChan()),
+			ouroboros_mock.ServerChanSize,
+		)
+	}
+}
+
+func TestServerConversationPerConnection(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// Each conversation answers with a different keep-alive cookie
+	keepAliveConversation := func(cookie uint16) []ouroboros_mock.ConversationEntry {
+		return []ouroboros_mock.ConversationEntry{
+			ouroboros_mock.ConversationEntryOutput{
+				ProtocolId: keepalive.ProtocolId,
+				IsResponse: true,
+				Messages: []protocol.Message{
+					keepalive.NewMsgKeepAliveResponse(cookie),
+				},
+			},
+		}
+	}
+	testDefs := []struct {
+		name string
+		opt  ouroboros_mock.ServerOptionFunc
+	}{
+		{
+			name: "round-robin",
+			opt: ouroboros_mock.WithConversations(
+				keepAliveConversation(1),
+				keepAliveConversation(2),
+				keepAliveConversation(3),
+			),
+		},
+		{
+			name: "by index",
+			opt: ouroboros_mock.WithConversationFunc(
+				func(index int) []ouroboros_mock.ConversationEntry {
+					return keepAliveConversation(uint16(index%3) + 1)
+				},
+			),
+		},
+	}
+	expectedCookies := []uint16{1, 2, 3, 1, 2}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			server := ouroboros_mock.NewServer(testDef.opt)
+			defer func() {
+				if err := server.Close(); err != nil {
+					t.Fatalf("unexpected error when closing server: %s", err)
+				}
+			}()
+			for i, expectedCookie := range expectedCookies {
+				clientConn, serverConn := net.Pipe()
+				server.ServeConn(serverConn)
+				if err := clientConn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+					t.Fatalf("unexpected error setting read deadline: %s", err)
+				}
+				data, err := io.ReadAll(clientConn)
+				clientConn.Close()
+				if err != nil {
+					t.Fatalf("unexpected error reading from connection %d: %s", i, err)
+				}
+				// Skip the segment header
+				if len(data) < 8 {
+					t.Fatalf("did not get expected response on connection %d: got %x", i, data)
+				}
+				var msg keepalive.MsgKeepAliveResponse
+				if _, err := cbor.Decode(data[8:], &msg); err != nil {
+					t.Fatalf("unexpected error decoding response on connection %d: %s", i, err)
+				}
+				if msg.Cookie != expectedCookie {
+					t.Fatalf(
+						"did not get expected cookie on connection %d: got %d, expected %d",
+						i,
+						msg.Cookie,
+						expectedCookie,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestServerBearerLatency(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	latency := 100 * time.Millisecond
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		),
+		ouroboros_mock.WithConnectionOptions(
+			ouroboros_mock.WithLatency(latency),
+		),
+	)
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	clientConn, serverConn := net.Pipe()
+	server.ServeConn(serverConn)
+	startTime := time.Now()
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(clientConn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// The handshake request and response should each be delayed
+	if elapsed := time.Since(startTime); elapsed < 2*latency {
+		t.Fatalf("handshake completed too quickly: got %s, expected at least %s", elapsed, 2*latency)
+	}
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+func TestServerBearerCloseStalledPeer(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryKeepAliveResponse,
+			},
+		),
+		ouroboros_mock.WithConnectionOptions(
+			ouroboros_mock.WithLatency(10*time.Second),
+		),
+	)
+	// The client end of the pipe is never read, so writes to the server end block
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	server.ServeConn(serverConn)
+	// Give the conversation time to queue its output
+	time.Sleep(100 * time.Millisecond)
+	closeErrChan := make(chan error, 1)
+	go func() {
+		closeErrChan <- server.Close()
+	}()
+	select {
+	case err := <-closeErrChan:
+		if err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server did not close within timeout")
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	cert := generateTestCertificate(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		),
+		ouroboros_mock.WithTLSConfig(
+			&tls.Config{
+				Certificates: []tls.Certificate{cert},
+			},
+		),
+	)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("unexpected error when starting server: %s", err)
+	}
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	certPool := x509.NewCertPool()
+	certPool.AddCert(cert.Leaf)
+	conn, err := tls.Dial(
+		"tcp",
+		server.Addr().String(),
+		&tls.Config{
+			RootCAs:    certPool,
+			ServerName: "localhost",
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when connecting to server: %s", err)
+	}
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(conn),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}
+
+// generateTestCertificate returns a self-signed certificate for localhost
+func generateTestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %s", err)
+	}
+	leaf, err := x509.ParseCertificate(certDer)
+	if err != nil {
+		t.Fatalf("unexpected error parsing certificate: %s", err)
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{certDer},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func TestServerMultipleListeners(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	// Serve NtN over TCP and NtC over a UNIX socket, like a real node
+	testDefs := []struct {
+		name        string
+		opts        []ouroboros_mock.ServerOptionFunc
+		response    ouroboros_mock.ConversationEntryOutput
+		nodeToNode  bool
+		dialNetwork string
+	}{
+		{
+			name:        "tcp",
+			response:    ouroboros_mock.ConversationEntryHandshakeNtNResponse,
+			nodeToNode:  true,
+			dialNetwork: "tcp",
+		},
+		{
+			name: "unix",
+			opts: []ouroboros_mock.ServerOptionFunc{
+				ouroboros_mock.WithNetwork("unix"),
+				ouroboros_mock.WithAddress(
+					filepath.Join(t.TempDir(), "node.socket"),
+				),
+			},
+			response:    ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			dialNetwork: "unix",
+		},
+	}
+	var servers []*ouroboros_mock.Server
+	defer func() {
+		for _, server := range servers {
+			if err := server.Close(); err != nil {
+				t.Fatalf("unexpected error when closing server: %s", err)
+			}
+		}
+	}()
+	for _, testDef := range testDefs {
+		server := ouroboros_mock.NewServer(
+			append(
+				[]ouroboros_mock.ServerOptionFunc{
+					ouroboros_mock.WithConversation(
+						[]ouroboros_mock.ConversationEntry{
+							ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+							testDef.response,
+						},
+					),
+				},
+				testDef.opts...,
+			)...,
+		)
+		if err := server.Listen(); err != nil {
+			t.Fatalf("%s: unexpected error when starting server: %s", testDef.name, err)
+		}
+		servers = append(servers, server)
+	}
+	for idx, testDef := range testDefs {
+		server := servers[idx]
+		conn, err := net.Dial(testDef.dialNetwork, server.Addr().String())
+		if err != nil {
+			t.Fatalf("%s: unexpected error when connecting to server: %s", testDef.name, err)
+		}
+		oConn, err := ouroboros.New(
+			ouroboros.WithConnection(conn),
+			ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+			ouroboros.WithNodeToNode(testDef.nodeToNode),
+		)
+		if err != nil {
+			t.Fatalf("%s: unexpected error when creating Ouroboros object: %s", testDef.name, err)
+		}
+		// Wait for the conversation to complete
+		select {
+		case <-server.CompleteChan():
+		case err := <-server.ErrorChan():
+			t.Fatalf("%s: unexpected conversation error: %s", testDef.name, err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: conversation did not complete within timeout", testDef.name)
+		}
+		// Close Ouroboros connection
+		if err := oConn.Close(); err != nil {
+			t.Fatalf("%s: unexpected error when closing Ouroboros object: %s", testDef.name, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol"
+)
+
+// segmentHeaderSize is the size of the muxer segment header in bytes
+const segmentHeaderSize = 8
+
+// EntryFunc is a function that is called after a conversation entry has been processed
+type EntryFunc func(entry ConversationEntry)
+
+// WithOnEntry specifies a function to call after each conversation entry has been processed. It may be called from
+// multiple goroutines when parallel conversations are running
+func WithOnEntry(entryFunc EntryFunc) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.onEntry = entryFunc
+	}
+}
+
+// MessageKey identifies a message type for a mini-protocol
+type MessageKey struct {
+	ProtocolId  uint16
+	MessageType uint
+}
+
+// ConnectionStats contains the progress of a conversation and counts of the traffic on the connection
+type ConnectionStats struct {
+	EntriesProcessed int
+	MessagesIn       map[MessageKey]int
+	MessagesOut      map[MessageKey]int
+	BytesIn          uint64
+	BytesOut         uint64
+}
+
+// connectionStats tracks ConnectionStats for a connection
+type connectionStats struct {
+	sync.Mutex
+	stats ConnectionStats
+}
+
+// Stats returns the progress of the conversation and counts of the traffic on the connection so far. Inbound
+// traffic is counted when it's read from the muxer, with the bytes counted for each segment on the wire and each
+// message counted separately when a client sends several in one segment. Outbound traffic is counted when it's sent,
+// including the header of each segment when a payload is split across several segments
+func (c *Connection) Stats() ConnectionStats {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	ret := c.stats.stats
+	ret.MessagesIn = maps.Clone(c.stats.stats.MessagesIn)
+	ret.MessagesOut = maps.Clone(c.stats.stats.MessagesOut)
+	return ret
+}
+
+// entryProcessed records that a conversation entry was processed
+func (c *Connection) entryProcessed(entry ConversationEntry) {
+	c.stats.Lock()
+	c.stats.stats.EntriesProcessed++
+	c.stats.Unlock()
+	if c.onEntry != nil {
+		c.onEntry(entry)
+	}
+}
+
+// segmentReceived records the size of a segment received from the client
+func (c *Connection) segmentReceived(segment *muxer.Segment) {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	c.stats.stats.BytesIn += uint64(segmentHeaderSize + len(segment.Payload))
+}
+
+// messageReceived records a message received from the client
+func (c *Connection) messageReceived(protocolId uint16, payload []byte) {
+	msgType, err := cbor.DecodeIdFromList(payload)
+	if err != nil {
+		return
+	}
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	if c.stats.stats.MessagesIn == nil {
+		c.stats.stats.MessagesIn = make(map[MessageKey]int)
+	}
+	c.stats.stats.MessagesIn[MessageKey{ProtocolId: protocolId, MessageType: uint(msgType)}]++
+}
+
+// payloadSent records a payload sent to the client in the provided number of segments
+func (c *Connection) payloadSent(
+	protocolId uint16,
+	msgs []protocol.Message,
+	payload []byte,
+	segmentCount int,
+) {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	c.stats.stats.BytesOut += uint64(segmentCount*segmentHeaderSize + len(payload))
+	if c.stats.stats.MessagesOut == nil {
+		c.stats.stats.MessagesOut = make(map[MessageKey]int)
+	}
+	for _, msg := range msgs {
+		c.stats.stats.MessagesOut[MessageKey{ProtocolId: protocolId, MessageType: uint(msg.Type())}]++
+	}
+}
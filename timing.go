@@ -0,0 +1,80 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"sync"
+	"time"
+)
+
+// WithTimeScale specifies a factor applied to the timing of the conversation, which includes sleep entries and the
+// SendAfter and MinGap delays of output entries. A factor of 2 plays the conversation back at half speed, a factor
+// of 0.5 plays it back at double speed, and a factor of 0 removes the delays entirely. Simulated bearer latency is
+// not affected
+func WithTimeScale(scale float64) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.timing.scale = max(scale, 0)
+	}
+}
+
+// timingState tracks the timing of the conversation for a connection
+type timingState struct {
+	sync.Mutex
+	scale    float64
+	lastSend time.Time
+}
+
+// scaleDuration returns the provided duration adjusted by the time scale factor
+func (c *Connection) scaleDuration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * c.timing.scale)
+}
+
+// sleep waits for the provided duration. It returns false if the connection was closed while waiting
+func (c *Connection) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-c.doneChan:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// waitOutputTiming waits for the SendAfter and MinGap delays from an output entry. It returns false if the
+// connection was closed while waiting
+func (c *Connection) waitOutputTiming(entry ConversationEntryOutput) bool {
+	delay := c.scaleDuration(entry.SendAfter)
+	if entry.MinGap > 0 {
+		c.timing.Lock()
+		lastSend := c.timing.lastSend
+		c.timing.Unlock()
+		if !lastSend.IsZero() {
+			delay = max(
+				delay,
+				time.Until(lastSend.Add(c.scaleDuration(entry.MinGap))),
+			)
+		}
+	}
+	return c.sleep(delay)
+}
+
+// markSent records the time that a segment was sent, for use with MinGap
+func (c *Connection) markSent() {
+	c.timing.Lock()
+	c.timing.lastSend = time.Now()
+	c.timing.Unlock()
+}
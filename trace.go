@@ -0,0 +1,146 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ouroboros_mock
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol"
+)
+
+// Trace event directions
+const (
+	TraceDirectionIn  = "in"  // Segment received from the client
+	TraceDirectionOut = "out" // Segment sent to the client
+)
+
+// WithTrace specifies a writer that receives a JSON-lines trace of every segment sent and received
+func WithTrace(w io.Writer) ConnectionOptionFunc {
+	return func(c *Connection) {
+		c.tracer = &tracer{
+			encoder: json.NewEncoder(w),
+		}
+	}
+}
+
+// TraceEvent is a single line of a connection trace. MessageType is the type of the first message in the segment
+// and is omitted if it can't be decoded
+type TraceEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Direction   string    `json:"direction"`
+	ProtocolId  uint16    `json:"protocol_id"`
+	MessageType *uint     `json:"message_type,omitempty"`
+	Cbor        string    `json:"cbor"`
+}
+
+// tracer writes trace events for a connection
+type tracer struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// trace writes a trace event for a segment, if tracing is enabled
+func (c *Connection) trace(direction string, protocolId uint16, payload []byte) {
+	if c.tracer == nil {
+		return
+	}
+	event := TraceEvent{
+		Timestamp:  time.Now().UTC(),
+		Direction:  direction,
+		ProtocolId: protocolId,
+		Cbor:       hex.EncodeToString(payload),
+	}
+	if msgType, err := cbor.DecodeIdFromList(payload); err == nil {
+		tmpMsgType := uint(msgType)
+		event.MessageType = &tmpMsgType
+	}
+	c.tracer.Lock()
+	defer c.tracer.Unlock()
+	// Errors writing the trace shouldn't interrupt the conversation
+	_ = c.tracer.encoder.Encode(event)
+}
+
+// NewConversationFromTrace returns a conversation that replays a trace written by WithTrace for a connection with
+// the provided protocol role. Segments received from the client become input entries that match the recorded
+// message type, and segments sent to the client become output entries with the recorded payload. Each output entry
+// has a SendAfter delay matching the time since the previous recorded segment, so the conversation plays back with
+// the original timing, which can be adjusted with WithTimeScale
+func NewConversationFromTrace(
+	r io.Reader,
+	protocolRole ProtocolRole,
+) ([]ConversationEntry, error) {
+	var ret []ConversationEntry
+	var prevTimestamp time.Time
+	decoder := json.NewDecoder(r)
+	for idx := 0; ; idx++ {
+		var event TraceEvent
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return ret, nil
+			}
+			return nil, fmt.Errorf("trace event %d: %w", idx, err)
+		}
+		payload, err := hex.DecodeString(event.Cbor)
+		if err != nil {
+			return nil, fmt.Errorf("trace event %d: %w", idx, err)
+		}
+		if event.MessageType == nil {
+			return nil, fmt.Errorf("trace event %d: missing message type", idx)
+		}
+		var delay time.Duration
+		if !prevTimestamp.IsZero() {
+			delay = max(event.Timestamp.Sub(prevTimestamp), 0)
+		}
+		prevTimestamp = event.Timestamp
+		switch event.Direction {
+		case TraceDirectionIn:
+			ret = append(
+				ret,
+				ConversationEntryInput{
+					ProtocolId:  event.ProtocolId,
+					IsResponse:  protocolRole == ProtocolRoleServer,
+					MessageType: *event.MessageType,
+				},
+			)
+		case TraceDirectionOut:
+			msg := &protocol.MessageBase{
+				MessageType: uint8(*event.MessageType),
+			}
+			msg.SetCbor(payload)
+			ret = append(
+				ret,
+				ConversationEntryOutput{
+					ProtocolId: event.ProtocolId,
+					IsResponse: protocolRole == ProtocolRoleClient,
+					Messages:   []protocol.Message{msg},
+					SendAfter:  delay,
+				},
+			)
+		default:
+			return nil, fmt.Errorf(
+				"trace event %d: unknown direction: %s",
+				idx,
+				event.Direction,
+			)
+		}
+	}
+}
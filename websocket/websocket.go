@@ -0,0 +1,130 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	gorilla_websocket "github.com/gorilla/websocket"
+)
+
+// Conn adapts a WebSocket connection to a net.Conn. Each write is sent as a binary message, and reads return the
+// contents of binary messages as a continuous stream
+type Conn struct {
+	ws     *gorilla_websocket.Conn
+	reader io.Reader
+}
+
+// NewConn returns a new Conn for the provided WebSocket connection
+func NewConn(ws *gorilla_websocket.Conn) *Conn {
+	return &Conn{
+		ws: ws,
+	}
+}
+
+// Read reads data from binary messages, moving on to the next message when the current one is exhausted
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			msgType, reader, err := c.ws.NextReader()
+			if err != nil {
+				var closeErr *gorilla_websocket.CloseError
+				if errors.As(err, &closeErr) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if msgType != gorilla_websocket.BinaryMessage {
+				continue
+			}
+			c.reader = reader
+		}
+		n, err := c.reader.Read(b)
+		if errors.Is(err, io.EOF) {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Write sends the provided data as a single binary message
+func (c *Conn) Write(b []byte) (int, error) {
+	if err := c.ws.WriteMessage(gorilla_websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
+
+// LocalAddr returns the local address of the underlying connection
+func (c *Conn) LocalAddr() net.Addr {
+	return c.ws.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the underlying connection
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection
+func (c *Conn) SetDeadline(t time.Time) error {
+	if err := c.ws.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.ws.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.ws.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.ws.SetWriteDeadline(t)
+}
+
+// NewHandler returns an HTTP handler that upgrades requests to WebSocket connections and runs the server's
+// conversation on them
+func NewHandler(server *ouroboros_mock.Server) http.Handler {
+	upgrader := gorilla_websocket.Upgrader{
+		// Allow connections from browsers on any origin
+		CheckOrigin: func(*http.Request) bool {
+			return true
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has already responded with an error
+			return
+		}
+		server.ServeConn(NewConn(ws))
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/blinklabs-io/ouroboros-mock/websocket"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	gorilla_websocket "github.com/gorilla/websocket"
+	"go.uber.org/goleak"
+)
+
+func TestWebSocket(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := ouroboros_mock.NewServer(
+		ouroboros_mock.WithConversation(
+			[]ouroboros_mock.ConversationEntry{
+				ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+				ouroboros_mock.ConversationEntryHandshakeNtCResponse,
+			},
+		),
+	)
+	httpServer := httptest.NewServer(websocket.NewHandler(server))
+	defer httpServer.Close()
+	defer func() {
+		if err := server.Close(); err != nil {
+			t.Fatalf("unexpected error when closing server: %s", err)
+		}
+	}()
+	ws, _, err := gorilla_websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(httpServer.URL, "http"),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when connecting to server: %s", err)
+	}
+	oConn, err := ouroboros.New(
+		ouroboros.WithConnection(websocket.NewConn(ws)),
+		ouroboros.WithNetworkMagic(ouroboros_mock.MockNetworkMagic),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error when creating Ouroboros object: %s", err)
+	}
+	// Wait for the conversation to complete
+	select {
+	case <-server.CompleteChan():
+	case err := <-server.ErrorChan():
+		t.Fatalf("unexpected conversation error: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("conversation did not complete within timeout")
+	}
+	// Close Ouroboros connection
+	if err := oConn.Close(); err != nil {
+		t.Fatalf("unexpected error when closing Ouroboros object: %s", err)
+	}
+}